@@ -0,0 +1,354 @@
+package dataprovider
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultEnvPrefix is the prefix of the environment variables read by LoadOptions when none is given
+const DefaultEnvPrefix = "DATAPROVIDER"
+
+// fileSuffix marks a key whose value is read from the file it names, for secrets mounted as files
+const fileSuffix = "_file"
+
+// ConfigError reports a configuration key that could not be applied, Key is the name used in the
+// source, for example DATAPROVIDER_PORT for the environment or port for a file
+type ConfigError struct {
+	Source string
+	Key    string
+	Err    error
+}
+
+func (e *ConfigError) Error() string {
+	return fmt.Sprintf("%s: %s: %v", e.Source, e.Key, e.Err)
+}
+
+func (e *ConfigError) Unwrap() error {
+	return e.Err
+}
+
+// configKey applies the value of a configuration key to the options
+type configKey struct {
+	name  string
+	apply func(o *Options, value string) error
+}
+
+// configKeys are applied in this order, dsn comes first so the other keys override its parts
+var configKeys = []configKey{
+	{"dsn", applyDSN},
+	{"driver", stringKey(func(o *Options) *string { return &o.Driver })},
+	{"name", stringKey(func(o *Options) *string { return &o.Name })},
+	{"host", stringKey(func(o *Options) *string { return &o.Host })},
+	{"port", intKey(func(o *Options) *int { return &o.Port })},
+	{"username", stringKey(func(o *Options) *string { return &o.Username })},
+	{"password", stringKey(func(o *Options) *string { return &o.Password })},
+	{"schema", stringKey(func(o *Options) *string { return &o.Schema })},
+	{"sql_tables_prefix", stringKey(func(o *Options) *string { return &o.SQLTablesPrefix })},
+	{"pool_size", intKey(func(o *Options) *int { return &o.PoolSize })},
+	{"max_open_conns", intKey(func(o *Options) *int { return &o.Pool.MaxOpenConns })},
+	{"max_idle_conns", intKey(func(o *Options) *int { return &o.Pool.MaxIdleConns })},
+	{"conn_max_lifetime", durationKey(func(o *Options) *time.Duration { return &o.Pool.ConnMaxLifetime })},
+	{"conn_max_idle_time", durationKey(func(o *Options) *time.Duration { return &o.Pool.ConnMaxIdleTime })},
+	{"health_timeout", durationKey(func(o *Options) *time.Duration { return &o.HealthTimeout })},
+	{"health_query", stringKey(func(o *Options) *string { return &o.HealthQuery })},
+	{"reconnect_max_attempts", intKey(func(o *Options) *int { return &o.Reconnect.MaxAttempts })},
+	{"reconnect_initial_backoff", durationKey(func(o *Options) *time.Duration { return &o.Reconnect.InitialBackoff })},
+	{"reconnect_max_backoff", durationKey(func(o *Options) *time.Duration { return &o.Reconnect.MaxBackoff })},
+	{"shutdown_timeout", durationKey(func(o *Options) *time.Duration { return &o.ShutdownTimeout })},
+	{"connection_string", stringKey(func(o *Options) *string { return &o.ConnectionString })},
+	{"params", applyParams},
+	{"tls_mode", stringKey(func(o *Options) *string { return (*string)(&o.TLS.Mode) })},
+	{"tls_ca_file", stringKey(func(o *Options) *string { return &o.TLS.CAFile })},
+	{"tls_cert_file", stringKey(func(o *Options) *string { return &o.TLS.CertFile })},
+	{"tls_key_file", stringKey(func(o *Options) *string { return &o.TLS.KeyFile })},
+	{"tls_server_name", stringKey(func(o *Options) *string { return &o.TLS.ServerName })},
+	{"tls_wallet_dir", stringKey(func(o *Options) *string { return &o.TLS.WalletDir })},
+	{"migrations_path", stringKey(func(o *Options) *string { return &o.MigrationsPath })},
+	{"reapply_on_reset", boolKey(func(o *Options) *bool { return &o.ReapplyOnReset })},
+	{"migration_lock_stale_after", durationKey(func(o *Options) *time.Duration { return &o.MigrationLockStaleAfter })},
+}
+
+// LoadOptions creates options from configuration files and environment variables.
+//
+// Files are JSON or YAML, chosen by extension, with the keys dsn, driver, name, host, port, username,
+// password, schema, sql_tables_prefix, pool_size, max_open_conns, max_idle_conns, conn_max_lifetime,
+// conn_max_idle_time, health_timeout, health_query, connection_string, params, tls_mode, tls_ca_file,
+// tls_cert_file, tls_key_file, tls_server_name, tls_wallet_dir, migrations_path, reapply_on_reset and
+// migration_lock_stale_after at the top level, durations use the time.ParseDuration format. The environment variables are the same keys
+// upper-cased behind the prefix, DATAPROVIDER_DRIVER, DATAPROVIDER_HOST and so on when prefix is empty. A
+// key with the _FILE suffix (_file in files) reads the value from the file it names, which is how secrets
+// mounted by orchestrators are used.
+//
+// Precedence from lowest to highest: NewOptions defaults, files in the given order, environment variables.
+// Within a source dsn is applied first and the other keys override its parts. Every invalid key is
+// reported as a *ConfigError
+func LoadOptions(prefix string, files ...string) (*Options, error) {
+	if prefix == "" {
+		prefix = DefaultEnvPrefix
+	}
+
+	opts := NewOptions()
+
+	var errs []error
+	for _, file := range files {
+		values, err := readConfigFile(file)
+		if err != nil {
+			return nil, err
+		}
+		errs = append(errs, applyConfig(opts, file, values)...)
+	}
+
+	errs = append(errs, applyConfig(opts, "env", envConfig(prefix))...)
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	return opts, nil
+}
+
+// configValue is a raw value and the key it was read from
+type configValue struct {
+	key   string
+	value string
+	file  bool
+}
+
+// applyConfig applies the known keys in order and reports the unknown ones
+func applyConfig(opts *Options, source string, values map[string][]configValue) []error {
+	var errs []error
+
+	known := make(map[string]struct{}, len(configKeys))
+	for _, key := range configKeys {
+		known[key.name] = struct{}{}
+
+		set := values[key.name]
+		if len(set) == 0 {
+			continue
+		}
+
+		if len(set) > 1 {
+			errs = append(errs, &ConfigError{Source: source, Key: set[0].key, Err: fmt.Errorf("%s is also set", set[1].key)})
+			continue
+		}
+
+		value := set[0].value
+		if set[0].file {
+			content, err := os.ReadFile(value)
+			if err != nil {
+				errs = append(errs, &ConfigError{Source: source, Key: set[0].key, Err: err})
+				continue
+			}
+			value = strings.TrimRight(string(content), "\r\n")
+		}
+
+		if err := key.apply(opts, value); err != nil {
+			errs = append(errs, &ConfigError{Source: source, Key: set[0].key, Err: err})
+		}
+	}
+
+	var unknown []string
+	for name, set := range values {
+		if _, ok := known[name]; !ok {
+			unknown = append(unknown, set[0].key)
+		}
+	}
+	sort.Strings(unknown)
+
+	for _, key := range unknown {
+		errs = append(errs, &ConfigError{Source: source, Key: key, Err: errors.New("unknown key")})
+	}
+
+	return errs
+}
+
+// envConfig reads the environment variables of the known keys
+func envConfig(prefix string) map[string][]configValue {
+	values := make(map[string][]configValue)
+	for _, key := range configKeys {
+		name := prefix + "_" + strings.ToUpper(key.name)
+
+		if value, ok := os.LookupEnv(name); ok {
+			values[key.name] = append(values[key.name], configValue{key: name, value: value})
+		}
+
+		fileName := name + strings.ToUpper(fileSuffix)
+		if value, ok := os.LookupEnv(fileName); ok {
+			values[key.name] = append(values[key.name], configValue{key: fileName, value: value, file: true})
+		}
+	}
+	return values
+}
+
+// readConfigFile decodes a JSON or YAML configuration file
+func readConfigFile(path string) (map[string][]configValue, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := make(map[string]any)
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = json.Unmarshal(content, &raw)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, &raw)
+	default:
+		return nil, fmt.Errorf("%s: unsupported configuration file format", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	values := make(map[string][]configValue, len(raw))
+	for key, value := range raw {
+		// tls_ca_file and friends are keys of their own, the suffix only applies to the other keys
+		name, file := key, false
+		if !isConfigKey(key) {
+			name, file = strings.CutSuffix(key, fileSuffix)
+		}
+
+		rendered, err := configString(value)
+		if err != nil {
+			return nil, &ConfigError{Source: path, Key: key, Err: err}
+		}
+
+		values[name] = append(values[name], configValue{key: key, value: rendered, file: file})
+	}
+
+	// report conflicts in a stable order
+	for _, set := range values {
+		sort.Slice(set, func(i, j int) bool { return len(set[i].key) < len(set[j].key) })
+	}
+
+	return values, nil
+}
+
+// isConfigKey tells if name is one of the configuration keys
+func isConfigKey(name string) bool {
+	for _, key := range configKeys {
+		if key.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// configString renders a decoded file value as the string an environment variable would hold, maps
+// are rendered as a query string
+func configString(value any) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case bool, int, int64, uint64, float64:
+		return fmt.Sprint(v), nil
+	case map[string]any:
+		query := url.Values{}
+		for key, item := range v {
+			rendered, err := configString(item)
+			if err != nil {
+				return "", err
+			}
+			query.Set(key, rendered)
+		}
+		return query.Encode(), nil
+	}
+
+	return "", fmt.Errorf("unsupported value %v", value)
+}
+
+func stringKey(field func(o *Options) *string) func(*Options, string) error {
+	return func(o *Options, value string) error {
+		*field(o) = value
+		return nil
+	}
+}
+
+func intKey(field func(o *Options) *int) func(*Options, string) error {
+	return func(o *Options, value string) error {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%q is not an integer", value)
+		}
+		*field(o) = n
+		return nil
+	}
+}
+
+func durationKey(field func(o *Options) *time.Duration) func(*Options, string) error {
+	return func(o *Options, value string) error {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("%q is not a duration", value)
+		}
+		*field(o) = d
+		return nil
+	}
+}
+
+func boolKey(field func(o *Options) *bool) func(*Options, string) error {
+	return func(o *Options, value string) error {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%q is not a boolean", value)
+		}
+		*field(o) = b
+		return nil
+	}
+}
+
+// applyDSN sets the parts of the options found in the URL
+func applyDSN(o *Options, value string) error {
+	parsed, err := ParseDSN(value)
+	if err != nil {
+		return err
+	}
+
+	o.Driver = parsed.Driver
+	o.ConnectionString = ""
+
+	for dst, src := range map[*string]string{
+		&o.Name: parsed.Name, &o.Host: parsed.Host, &o.Username: parsed.Username,
+		&o.Password: parsed.Password, &o.Schema: parsed.Schema,
+	} {
+		if src != "" {
+			*dst = src
+		}
+	}
+
+	if parsed.Port > 0 {
+		o.Port = parsed.Port
+	}
+
+	for key, param := range parsed.Params {
+		WithParam(key, param)(o)
+	}
+
+	return nil
+}
+
+// applyParams adds driver parameters written as a query string, sslmode=require&connect_timeout=5
+func applyParams(o *Options, value string) error {
+	query, err := url.ParseQuery(value)
+	if err != nil {
+		return err
+	}
+
+	for key, values := range query {
+		WithParam(key, values[len(values)-1])(o)
+	}
+
+	return nil
+}
@@ -1,10 +1,12 @@
 package dataprovider
 
 import (
+	"context"
 	"fmt"
+	"io/fs"
 	"path/filepath"
+	"time"
 
-	"github.com/inovacc/dataprovider/internal/migration"
 	"github.com/inovacc/dataprovider/internal/provider"
 	"github.com/jmoiron/sqlx"
 	"github.com/spf13/afero"
@@ -30,53 +32,142 @@ const (
 type Status = provider.Status
 type Options = provider.Options
 
+// PoolOptions tunes the connection pool of a provider, zero values pick the driver default
+type PoolOptions = provider.PoolOptions
+
+// DefaultSQLiteWALReaders is the default number of open connections of a SQLite database in WAL mode
+const DefaultSQLiteWALReaders = provider.DefaultSQLiteWALReaders
+
+// HealthMonitor checks a provider periodically and reports the changes of its health
+type HealthMonitor = provider.HealthMonitor
+
+// HealthEvent is a change of the health of a provider
+type HealthEvent = provider.HealthEvent
+
+const (
+	// DefaultHealthTimeout bounds CheckAvailability when no health timeout is set
+	DefaultHealthTimeout = provider.DefaultHealthTimeout
+
+	// DefaultHealthInterval is the time between two checks of a HealthMonitor created without interval
+	DefaultHealthInterval = provider.DefaultHealthInterval
+)
+
+// NewHealthMonitor creates a monitor calling CheckAvailability of the provider every interval, call
+// Start to run it in the background
+func NewHealthMonitor(p Provider, interval time.Duration) *HealthMonitor {
+	return provider.NewHealthMonitor(p.CheckAvailability, interval)
+}
+
+// ReconnectOptions configures the exponential backoff of ReconnectDatabase
+type ReconnectOptions = provider.ReconnectOptions
+
+const (
+	// DefaultReconnectAttempts is the number of attempts of ReconnectDatabase when none is set
+	DefaultReconnectAttempts = provider.DefaultReconnectAttempts
+
+	// DefaultReconnectInitialBackoff is the wait after the first failed attempt
+	DefaultReconnectInitialBackoff = provider.DefaultReconnectInitialBackoff
+
+	// DefaultReconnectMaxBackoff caps the wait between two attempts
+	DefaultReconnectMaxBackoff = provider.DefaultReconnectMaxBackoff
+
+	// DefaultShutdownTimeout bounds the wait of Disconnect for the connections in use
+	DefaultShutdownTimeout = provider.DefaultShutdownTimeout
+)
+
+// TLSOptions configures TLS for the network providers
+type TLSOptions = provider.TLSOptions
+
+// TLSMode selects how the server certificate is checked
+type TLSMode = provider.TLSMode
+
+const (
+	// TLSDisable connects without TLS
+	TLSDisable = provider.TLSDisable
+
+	// TLSRequire encrypts the connection without checking the server certificate
+	TLSRequire = provider.TLSRequire
+
+	// TLSVerifyCA checks that the server certificate is signed by a trusted CA
+	TLSVerifyCA = provider.TLSVerifyCA
+
+	// TLSVerifyFull checks the CA and that the certificate matches the server name
+	TLSVerifyFull = provider.TLSVerifyFull
+)
+
 type Provider interface {
-	// Disconnect disconnects from the data provider
+	// Disconnect waits up to the shutdown timeout for the connections in use, then closes the pool,
+	// calling it again does nothing
 	Disconnect() error
 
+	// DisconnectContext stops accepting work through the provider and closes the pool once the
+	// connections in use are released or ctx is done
+	DisconnectContext(ctx context.Context) error
+
 	// GetConnection returns the connection to the data provider
 	GetConnection() *sqlx.DB
 
 	// CheckAvailability checks if the data provider is available
 	CheckAvailability() error
 
-	// ReconnectDatabase reconnects to the database
+	// CheckAvailabilityContext checks if the data provider is available within ctx and the health timeout
+	CheckAvailabilityContext(ctx context.Context) error
+
+	// ReconnectDatabase opens new connections to the database, retrying with the backoff of the reconnect
+	// options, the handle of GetConnection stays the same
 	ReconnectDatabase() error
 
+	// ReconnectDatabaseContext reconnects like ReconnectDatabase, ctx cancels the retries
+	ReconnectDatabaseContext(ctx context.Context) error
+
 	// InitializeDatabase initializes the database
 	InitializeDatabase(schema string) error
 
+	// InitializeDatabaseContext initializes the database with ctx
+	InitializeDatabaseContext(ctx context.Context, schema string) error
+
 	// MigrateDatabase migrates the database to the latest version
-	MigrateDatabase() migration.Migration
+	MigrateDatabase() Migration
+
+	// MigrateDatabaseContext returns the migration engine, its operations run with ctx
+	MigrateDatabaseContext(ctx context.Context) Migration
 
 	// RevertDatabase reverts the database to the specified version
 	RevertDatabase(targetVersion int) error
 
+	// RevertDatabaseContext reverts the database to the specified version with ctx
+	RevertDatabaseContext(ctx context.Context, targetVersion int) error
+
 	// ResetDatabase resets the database
 	ResetDatabase() error
 
+	// ResetDatabaseContext resets the database with ctx
+	ResetDatabaseContext(ctx context.Context) error
+
 	// GetProviderStatus returns the status of the provider
 	GetProviderStatus() Status
 
+	// GetProviderStatusContext returns the status of the provider, ctx bounds the availability check
+	GetProviderStatusContext(ctx context.Context) Status
+
 	SqlBuilder() *provider.SQLBuilder
 }
 
-// NewDataProvider creates a new data provider instance
+// NewDataProvider validates the options and creates a provider with the factory of the driver
 func NewDataProvider(options *Options) (Provider, error) {
-	switch options.Driver {
-	case OracleDatabaseProviderName:
-		return provider.NewOracleProvider(options)
-	case SQLiteDataProviderName:
-		return provider.NewSQLiteProvider(options)
-	case MySQLDatabaseProviderName:
-		return provider.NewMySQLProvider(options)
-	case PostgresSQLDatabaseProviderName:
-		return provider.NewPostgreSQLProvider(options)
-	case MemoryDataProviderName:
-		return provider.NewMemoryProvider(options)
+	if err := options.Validate(); err != nil {
+		return nil, err
 	}
 
-	return nil, fmt.Errorf("unsupported driver %s", options.Driver)
+	factory, ok := driverFactory(options.Driver)
+	if !ok {
+		if err := provider.NotCompiled(options.Driver); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w %q", ErrUnsupportedDriver, options.Driver)
+	}
+
+	return factory(options)
 }
 
 // Must panics if the error is not nil
@@ -89,9 +180,18 @@ func Must(provider Provider, err error) Provider {
 	return provider
 }
 
+// GetQueryFromFile reads a SQL query from a file of the OS filesystem
 func GetQueryFromFile(filename string) (string, error) {
-	fs := afero.NewOsFs()
+	return GetQueryFromAferoFs(afero.NewOsFs(), filename)
+}
+
+// GetQueryFromFS reads a SQL query from a file of fsys, for example an embed.FS
+func GetQueryFromFS(fsys fs.FS, filename string) (string, error) {
+	return GetQueryFromAferoFs(afero.FromIOFS{FS: fsys}, filename)
+}
 
+// GetQueryFromAferoFs reads a SQL query from a file of an afero filesystem
+func GetQueryFromAferoFs(fs afero.Fs, filename string) (string, error) {
 	ok, err := afero.DirExists(fs, filepath.Dir(filename))
 	if err != nil {
 		return "", err
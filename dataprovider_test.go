@@ -1,11 +1,24 @@
 package dataprovider
 
 import (
+	"context"
+	"embed"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/jmoiron/sqlx"
+	"github.com/spf13/afero"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
+//go:embed internal/testdata
+var testdata embed.FS
+
 func TestNewMemoryProvider(t *testing.T) {
 	provider := Must(NewDataProvider(NewOptions(WithMemoryDB())))
 
@@ -37,3 +50,521 @@ func TestNewMemoryProvider(t *testing.T) {
 	err = tx.Commit()
 	assert.NoError(t, err)
 }
+
+func TestMigrateDatabase(t *testing.T) {
+	provider := Must(NewDataProvider(NewOptions(
+		WithSqliteDB("migrate", t.TempDir()),
+		WithMigrationsPath("internal/testdata/migrations"),
+	)))
+	defer func() { _ = provider.Disconnect() }()
+
+	err := provider.MigrateDatabase().Migrate()
+	assert.NoError(t, err)
+	assert.NoError(t, provider.MigrateDatabase().Validate(""))
+
+	var versions []int
+	err = provider.GetConnection().Select(&versions, "SELECT version FROM schema_migrations ORDER BY version")
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3}, versions)
+
+	_, err = provider.GetConnection().Exec("INSERT INTO users (ip_address, city, email) VALUES (?, ?, ?)", "83.121.11.105", "New York", "ny@example.com")
+	assert.NoError(t, err)
+}
+
+func TestRevertDatabase(t *testing.T) {
+	provider := Must(NewDataProvider(NewOptions(
+		WithSqliteDB("revert", t.TempDir()),
+		WithMigrationsPath("internal/testdata/migrations"),
+	)))
+	defer func() { _ = provider.Disconnect() }()
+
+	assert.NoError(t, provider.MigrateDatabase().Migrate())
+	assert.NoError(t, provider.RevertDatabase(1))
+
+	var versions []int
+	err := provider.GetConnection().Select(&versions, "SELECT version FROM schema_migrations ORDER BY version")
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1}, versions)
+
+	assert.Error(t, provider.RevertDatabase(2))
+}
+
+func TestResetDatabase(t *testing.T) {
+	provider := Must(NewDataProvider(NewOptions(
+		WithSqliteDB("reset", t.TempDir()),
+		WithMigrationsPath("internal/testdata/migrations"),
+		WithSQLTablesPrefix(""),
+		WithReapplyOnReset(false),
+	)))
+	defer func() { _ = provider.Disconnect() }()
+
+	conn := provider.GetConnection()
+	assert.NoError(t, provider.InitializeDatabase(`CREATE TABLE parents (id INTEGER PRIMARY KEY); CREATE VIEW parent_ids AS SELECT id FROM parents; CREATE TABLE "odd""name" (id INTEGER);`))
+	assert.NoError(t, provider.MigrateDatabase().Migrate())
+	assert.NoError(t, provider.ResetDatabase())
+
+	var count int
+	assert.NoError(t, conn.Get(&count, "SELECT COUNT(*) FROM sqlite_master WHERE name NOT LIKE 'sqlite_%'"))
+	assert.Equal(t, 0, count)
+}
+
+func TestResetDatabasePrefix(t *testing.T) {
+	provider := Must(NewDataProvider(NewOptions(
+		WithSqliteDB("reset_prefix", t.TempDir()),
+		WithSQLTablesPrefix("app_"),
+		WithReapplyOnReset(true),
+	)))
+	defer func() { _ = provider.Disconnect() }()
+
+	conn := provider.GetConnection()
+	assert.NoError(t, provider.InitializeDatabase("CREATE TABLE IF NOT EXISTS app_settings (name TEXT PRIMARY KEY, value TEXT);"))
+
+	_, err := conn.Exec("CREATE TABLE legacy (id INTEGER PRIMARY KEY); INSERT INTO app_settings (name, value) VALUES ('theme', 'dark')")
+	assert.NoError(t, err)
+
+	assert.NoError(t, provider.ResetDatabase())
+
+	// tables outside the prefix are kept and the schema is applied again
+	var tables []string
+	assert.NoError(t, conn.Select(&tables, "SELECT name FROM sqlite_master WHERE type = 'table' ORDER BY name"))
+	assert.Equal(t, []string{"app_settings", "legacy"}, tables)
+
+	var count int
+	assert.NoError(t, conn.Get(&count, "SELECT COUNT(*) FROM app_settings"))
+	assert.Equal(t, 0, count)
+}
+
+func TestResetDatabaseReapply(t *testing.T) {
+	provider := Must(NewDataProvider(NewOptions(
+		WithSqliteDB("reset_reapply", t.TempDir()),
+		WithMigrationsPath("internal/testdata/migrations"),
+		WithReapplyOnReset(true),
+	)))
+	defer func() { _ = provider.Disconnect() }()
+
+	conn := provider.GetConnection()
+	assert.NoError(t, provider.MigrateDatabase().Migrate())
+
+	_, err := conn.Exec("INSERT INTO users (ip_address, city) VALUES ('83.121.11.105', 'New York')")
+	assert.NoError(t, err)
+
+	assert.NoError(t, provider.ResetDatabase())
+
+	var count int
+	assert.NoError(t, conn.Get(&count, "SELECT COUNT(*) FROM users"))
+	assert.Equal(t, 0, count)
+
+	assert.NoError(t, conn.Get(&count, "SELECT COUNT(*) FROM schema_migrations"))
+	assert.Equal(t, 3, count)
+}
+
+func TestResetDatabaseReapplyGoMigrations(t *testing.T) {
+	provider := Must(NewDataProvider(NewOptions(
+		WithSqliteDB("reset_reapply_go", t.TempDir()),
+		WithGoMigration(1, "create_items", func(ctx context.Context, tx *sqlx.Tx) error {
+			_, err := tx.ExecContext(ctx, "CREATE TABLE items (id INTEGER PRIMARY KEY)")
+			return err
+		}, nil),
+		WithReapplyOnReset(true),
+	)))
+	defer func() { _ = provider.Disconnect() }()
+
+	require.NoError(t, provider.MigrateDatabase().Register(2, "create_tags", func(ctx context.Context, tx *sqlx.Tx) error {
+		_, err := tx.ExecContext(ctx, "CREATE TABLE tags (id INTEGER PRIMARY KEY)")
+		return err
+	}, nil))
+	require.NoError(t, provider.MigrateDatabase().Migrate())
+	require.NoError(t, provider.ResetDatabase())
+
+	// the Go migrations are applied again without a migrations path
+	var count int
+	assert.NoError(t, provider.GetConnection().Get(&count, "SELECT COUNT(*) FROM schema_migrations"))
+	assert.Equal(t, 2, count)
+	assert.NoError(t, provider.GetConnection().Get(&count, "SELECT COUNT(*) FROM tags"))
+
+	// a provider without any migration resets fine
+	empty := Must(NewDataProvider(NewOptions(WithMemoryDB(), WithReapplyOnReset(true))))
+	defer func() { _ = empty.Disconnect() }()
+	assert.NoError(t, empty.ResetDatabase())
+}
+
+func TestMigrateDatabaseFromEmbedFS(t *testing.T) {
+	provider := Must(NewDataProvider(NewOptions(
+		WithSqliteDB("migrate_embed", t.TempDir()),
+		WithMigrationsFS(testdata),
+		WithMigrationsPath("internal/testdata/migrations"),
+	)))
+	defer func() { _ = provider.Disconnect() }()
+
+	assert.NoError(t, provider.MigrateDatabase().Migrate())
+
+	query, err := GetQueryFromFS(testdata, "internal/testdata/sqlite/insert_user.sql")
+	assert.NoError(t, err)
+
+	_, err = provider.GetConnection().Exec(query, "83.121.11.105", "New York")
+	assert.NoError(t, err)
+
+	_, err = GetQueryFromFS(testdata, "internal/testdata/sqlite/missing.sql")
+	assert.Error(t, err)
+}
+
+func TestMigrateDatabaseWithGoMigration(t *testing.T) {
+	provider := Must(NewDataProvider(NewOptions(
+		WithSqliteDB("migrate_go", t.TempDir()),
+		WithMigrationsPath("internal/testdata/migrations"),
+		WithGoMigration(4, "backfill_email", func(ctx context.Context, tx *sqlx.Tx) error {
+			_, err := tx.ExecContext(ctx, "UPDATE users SET email = city || '@example.com' WHERE email IS NULL")
+			return err
+		}, nil),
+	)))
+	defer func() { _ = provider.Disconnect() }()
+
+	conn := provider.GetConnection()
+	assert.NoError(t, provider.MigrateDatabase().Migrate())
+
+	var versions []int
+	assert.NoError(t, conn.Select(&versions, "SELECT version FROM schema_migrations ORDER BY version"))
+	assert.Equal(t, []int{1, 2, 3, 4}, versions)
+
+	// the Go migration has no down function
+	assert.Error(t, provider.RevertDatabase(3))
+
+	// a Go migration registered on the engine is kept for the next calls and the revert
+	var reverted bool
+	require.NoError(t, provider.MigrateDatabase().Register(5, "flag", func(ctx context.Context, tx *sqlx.Tx) error {
+		return nil
+	}, func(ctx context.Context, tx *sqlx.Tx) error {
+		reverted = true
+		return nil
+	}))
+	assert.ErrorContains(t, provider.MigrateDatabaseContext(context.Background()).Register(5, "again", func(context.Context, *sqlx.Tx) error { return nil }, nil), "already registered")
+
+	assert.NoError(t, provider.MigrateDatabase().Migrate())
+	assert.NoError(t, conn.Select(&versions, "SELECT version FROM schema_migrations ORDER BY version"))
+	assert.Equal(t, []int{1, 2, 3, 4, 5}, versions)
+
+	assert.NoError(t, provider.RevertDatabase(4))
+	assert.True(t, reverted)
+}
+
+func TestMigrateDatabaseStaleLock(t *testing.T) {
+	provider := Must(NewDataProvider(NewOptions(
+		WithSqliteDB("migrate_stale", t.TempDir()),
+		WithMigrationsPath("internal/testdata/migrations"),
+		WithMigrationLockStaleAfter(time.Minute),
+	)))
+	defer func() { _ = provider.Disconnect() }()
+
+	conn := provider.GetConnection()
+	_, err := conn.Exec("CREATE TABLE schema_migrations_lock (id INTEGER PRIMARY KEY, holder TEXT NOT NULL, acquired_at TIMESTAMP NOT NULL)")
+	require.NoError(t, err)
+	_, err = conn.Exec("INSERT INTO schema_migrations_lock VALUES (1, 'crashed', ?)", time.Now().Add(-time.Hour).UTC())
+	require.NoError(t, err)
+
+	err = provider.MigrateDatabase().Migrate()
+	assert.ErrorIs(t, err, ErrStaleMigrationLock)
+	assert.ErrorContains(t, err, "crashed")
+}
+
+func TestMigrateDatabasePlan(t *testing.T) {
+	provider := Must(NewDataProvider(NewOptions(
+		WithSqliteDB("migrate_plan", t.TempDir()),
+		WithMigrationsPath("internal/testdata/migrations"),
+	)))
+	defer func() { _ = provider.Disconnect() }()
+
+	plan, err := provider.MigrateDatabase().Plan()
+	assert.NoError(t, err)
+	assert.Len(t, plan, 3)
+	assert.Equal(t, "internal/testdata/migrations/0001_create_users.up.sql", plan[0].Source)
+
+	assert.NoError(t, provider.MigrateDatabase().Migrate())
+
+	status, err := provider.MigrateDatabase().Status()
+	assert.NoError(t, err)
+	for _, st := range status {
+		assert.Equal(t, MigrationApplied, st.State)
+	}
+}
+
+func TestNewMigration(t *testing.T) {
+	provider := Must(NewDataProvider(NewOptions(WithSqliteDB("migrate_public", t.TempDir()))))
+	defer func() { _ = provider.Disconnect() }()
+
+	var m Migration = NewMigration(provider.GetConnection(), MigrationOptions{
+		Source: MigrationSource{
+			Path: "internal/testdata/migrations",
+			Fs:   afero.FromIOFS{FS: testdata},
+			GoMigrations: []GoMigration{
+				{Version: 4, Name: "noop", Up: func(context.Context, *sqlx.Tx) error { return nil }},
+			},
+		},
+		Driver: SQLiteDataProviderName,
+	})
+
+	assert.NoError(t, m.Migrate())
+
+	status, err := m.Status()
+	assert.NoError(t, err)
+	assert.Len(t, status, 4)
+	assert.True(t, status[3].Go)
+
+	_, err = m.RevertTo(3)
+	assert.ErrorIs(t, err, ErrMissingDownScript)
+}
+
+func TestNewOptionsIndependent(t *testing.T) {
+	first := NewOptions(WithSqliteDB("first", t.TempDir()), WithSQLTablesPrefix("app_"))
+	second := NewOptions()
+
+	assert.NotSame(t, first, second)
+	assert.Equal(t, SQLiteDataProviderName, first.Driver)
+	assert.Equal(t, MemoryDataProviderName, second.Driver)
+	assert.Empty(t, second.SQLTablesPrefix)
+}
+
+func TestMultipleProviders(t *testing.T) {
+	sqlite := Must(NewDataProvider(NewOptions(WithSqliteDB("multiple", t.TempDir()))))
+	defer func() { _ = sqlite.Disconnect() }()
+
+	cache := Must(NewDataProvider(NewOptions(WithMemoryDB())))
+	defer func() { _ = cache.Disconnect() }()
+
+	other := Must(NewDataProvider(NewOptions(WithMemoryDB())))
+	defer func() { _ = other.Disconnect() }()
+
+	assert.Equal(t, SQLiteDataProviderName, sqlite.GetProviderStatus().Driver)
+	assert.Equal(t, MemoryDataProviderName, cache.GetProviderStatus().Driver)
+	assert.Equal(t, MemoryDataProviderName, other.GetProviderStatus().Driver)
+
+	// every in-memory provider has its own database
+	assert.NoError(t, cache.InitializeDatabase("CREATE TABLE entries (key TEXT PRIMARY KEY, value TEXT)"))
+	assert.NoError(t, other.InitializeDatabase("CREATE TABLE entries (id INTEGER PRIMARY KEY)"))
+	assert.NoError(t, sqlite.InitializeDatabase("CREATE TABLE entries (name TEXT)"))
+
+	_, err := cache.GetConnection().Exec("INSERT INTO entries (key, value) VALUES ('a', 'b')")
+	assert.NoError(t, err)
+
+	var count int
+	assert.NoError(t, other.GetConnection().Get(&count, "SELECT COUNT(*) FROM entries"))
+	assert.Equal(t, 0, count)
+}
+
+func TestProviderStatus(t *testing.T) {
+	provider := Must(NewDataProvider(NewOptions(WithSqliteDB("status", t.TempDir()))))
+	defer func() { _ = provider.Disconnect() }()
+
+	status := provider.GetProviderStatus()
+	assert.Equal(t, SQLiteDataProviderName, status.Driver)
+	assert.True(t, status.IsActive)
+	assert.NoError(t, status.Error)
+	assert.WithinDuration(t, time.Now(), status.LastPing, time.Second)
+	assert.Positive(t, status.PingLatency)
+	assert.NotEmpty(t, status.ServerVersion)
+	assert.Positive(t, status.Uptime)
+	assert.Equal(t, 1, status.Stats.MaxOpenConnections)
+
+	out, err := json.Marshal(Status{Driver: MemoryDataProviderName, Error: errors.New("connection refused"), Uptime: 90 * time.Second})
+	assert.NoError(t, err)
+
+	var wire map[string]any
+	assert.NoError(t, json.Unmarshal(out, &wire))
+	assert.Equal(t, "connection refused", wire["error"])
+	assert.Equal(t, "1m30s", wire["uptime"])
+	assert.NotContains(t, wire, "last_ping")
+	assert.Contains(t, wire, "stats")
+
+	var decoded Status
+	assert.NoError(t, json.Unmarshal(out, &decoded))
+	assert.EqualError(t, decoded.Error, "connection refused")
+	assert.Equal(t, 90*time.Second, decoded.Uptime)
+}
+
+func TestCheckAvailability(t *testing.T) {
+	provider := Must(NewDataProvider(NewOptions(WithMemoryDB(), WithHealthQuery("SELECT 1"))))
+	defer func() { _ = provider.Disconnect() }()
+
+	assert.NoError(t, provider.CheckAvailability())
+
+	broken := Must(NewDataProvider(NewOptions(WithMemoryDB(), WithHealthQuery("SELECT * FROM missing_table"))))
+	defer func() { _ = broken.Disconnect() }()
+
+	assert.ErrorContains(t, broken.CheckAvailability(), "missing_table")
+	assert.False(t, broken.GetProviderStatus().IsActive)
+
+	endless := "WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM c) SELECT COUNT(*) FROM c"
+	slow := Must(NewDataProvider(NewOptions(WithMemoryDB(), WithHealthQuery(endless), WithHealthTimeout(50*time.Millisecond))))
+	defer func() { _ = slow.Disconnect() }()
+
+	start := time.Now()
+	assert.Error(t, slow.CheckAvailability())
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestHealthMonitor(t *testing.T) {
+	provider := Must(NewDataProvider(NewOptions(WithMemoryDB())))
+
+	events := make(chan HealthEvent, 4)
+	monitor := NewHealthMonitor(provider, 10*time.Millisecond)
+	monitor.OnChange(func(event HealthEvent) { events <- event })
+
+	monitor.Start(context.Background())
+	defer monitor.Stop()
+
+	select {
+	case event := <-events:
+		assert.True(t, event.Healthy)
+		assert.True(t, monitor.Healthy())
+	case <-time.After(time.Second):
+		t.Fatal("no event for the first check")
+	}
+
+	assert.NoError(t, provider.Disconnect())
+
+	select {
+	case event := <-events:
+		assert.False(t, event.Healthy)
+		assert.Error(t, event.Err)
+		assert.False(t, monitor.Healthy())
+	case <-time.After(time.Second):
+		t.Fatal("no event after the database was closed")
+	}
+
+	// no event while the state does not change
+	select {
+	case event := <-events:
+		t.Fatalf("unexpected event %+v", event)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestReconnectDatabase(t *testing.T) {
+	options := NewOptions(WithSqliteDB("reconnect", t.TempDir()),
+		WithReconnect(ReconnectOptions{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond}))
+	provider := Must(NewDataProvider(options))
+	defer func() { _ = provider.Disconnect() }()
+
+	_, err := provider.GetConnection().Exec("CREATE TABLE items (id INTEGER PRIMARY KEY); INSERT INTO items (id) VALUES (1)")
+	require.NoError(t, err)
+
+	old := provider.GetConnection()
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					assert.NotNil(t, provider.GetConnection())
+				}
+			}
+		}()
+	}
+
+	require.NoError(t, provider.ReconnectDatabase())
+	close(stop)
+	wg.Wait()
+
+	assert.Same(t, old, provider.GetConnection(), "the handle survives the reconnect")
+	assert.NoError(t, old.Ping(), "a handle taken before the reconnect keeps working")
+
+	var count int
+	require.NoError(t, provider.GetConnection().Get(&count, "SELECT COUNT(*) FROM items"))
+	assert.Equal(t, 1, count)
+
+	// a database that cannot be opened anymore keeps the current pool
+	current := provider.GetConnection()
+	options.Name = filepath.Join(t.TempDir(), "missing", "reconnect")
+
+	err = provider.ReconnectDatabase()
+	assert.ErrorIs(t, err, ErrReconnectFailed)
+	assert.ErrorContains(t, err, "after 3 attempts")
+	assert.Same(t, current, provider.GetConnection())
+	assert.NoError(t, provider.CheckAvailability())
+}
+
+func TestDisconnect(t *testing.T) {
+	provider := Must(NewDataProvider(NewOptions(WithSqliteDB("disconnect", t.TempDir()))))
+
+	tx, err := provider.GetConnection().Beginx()
+	require.NoError(t, err)
+	_, err = tx.Exec("CREATE TABLE items (id INTEGER PRIMARY KEY)")
+	require.NoError(t, err)
+
+	done := make(chan error, 1)
+	go func() { done <- provider.DisconnectContext(context.Background()) }()
+
+	// the provider rejects new work while the transaction is running
+	assert.Eventually(t, func() bool { return errors.Is(provider.CheckAvailability(), ErrProviderClosed) }, time.Second, time.Millisecond)
+	assert.ErrorIs(t, provider.InitializeDatabase("CREATE TABLE other (id INTEGER)"), ErrProviderClosed)
+	assert.ErrorIs(t, provider.ResetDatabase(), ErrProviderClosed)
+
+	select {
+	case <-done:
+		t.Fatal("disconnect returned before the transaction ended")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	require.NoError(t, tx.Commit())
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("disconnect did not return after the transaction ended")
+	}
+
+	assert.Error(t, provider.GetConnection().Ping())
+	assert.NoError(t, provider.Disconnect(), "disconnecting twice is safe")
+	assert.ErrorIs(t, provider.ReconnectDatabase(), ErrProviderClosed)
+	assert.ErrorIs(t, provider.RevertDatabase(0), ErrProviderClosed)
+	assert.ErrorIs(t, provider.MigrateDatabase().Migrate(), ErrProviderClosed)
+	_, err = provider.MigrateDatabase().Status()
+	assert.ErrorIs(t, err, ErrProviderClosed)
+	assert.False(t, provider.GetProviderStatus().IsActive)
+
+	// a transaction that outlives the deadline does not block the shutdown
+	stuck := Must(NewDataProvider(NewOptions(WithMemoryDB(), WithShutdownTimeout(20*time.Millisecond))))
+	stuckTx, err := stuck.GetConnection().Beginx()
+	require.NoError(t, err)
+	defer func() { _ = stuckTx.Rollback() }()
+
+	start := time.Now()
+	assert.ErrorIs(t, stuck.Disconnect(), context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.ErrorIs(t, stuck.CheckAvailability(), ErrProviderClosed)
+}
+
+func TestProviderContext(t *testing.T) {
+	provider := Must(NewDataProvider(NewOptions(WithMemoryDB(), WithMigrationsPath("internal/testdata/migrations"))))
+	defer func() { _ = provider.Disconnect() }()
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	assert.ErrorIs(t, provider.CheckAvailabilityContext(canceled), context.Canceled)
+	assert.False(t, provider.GetProviderStatusContext(canceled).IsActive)
+	assert.ErrorIs(t, provider.InitializeDatabaseContext(canceled, "CREATE TABLE items (id INTEGER)"), context.Canceled)
+	assert.ErrorIs(t, provider.MigrateDatabaseContext(canceled).Migrate(), context.Canceled)
+	assert.ErrorIs(t, provider.ResetDatabaseContext(canceled), context.Canceled)
+	assert.ErrorIs(t, provider.ReconnectDatabaseContext(canceled), context.Canceled)
+
+	// a request deadline stops a running statement
+	endless := "WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM c) SELECT COUNT(*) FROM c"
+	ctx, cancelTimeout := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancelTimeout()
+
+	start := time.Now()
+	assert.Error(t, provider.InitializeDatabaseContext(ctx, endless))
+	assert.Less(t, time.Since(start), 5*time.Second)
+
+	// the methods without context keep using the options context
+	assert.NoError(t, provider.CheckAvailability())
+	assert.NoError(t, provider.InitializeDatabase("CREATE TABLE items (id INTEGER)"))
+	assert.NoError(t, provider.MigrateDatabaseContext(context.Background()).Migrate())
+	assert.NoError(t, provider.ResetDatabaseContext(context.Background()))
+}
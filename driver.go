@@ -0,0 +1,111 @@
+package dataprovider
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/inovacc/dataprovider/internal/provider"
+)
+
+// ErrDriverNotCompiled is returned by NewDataProvider for a built-in driver left out of the build, the
+// error is a *DriverNotCompiledError naming the build tag
+var ErrDriverNotCompiled = provider.ErrDriverNotCompiled
+
+// DriverNotCompiledError reports a built-in driver whose provider needs a build tag
+type DriverNotCompiledError = provider.DriverNotCompiledError
+
+// DriverFactory creates the provider of a registered driver from validated options
+type DriverFactory func(*Options) (Provider, error)
+
+// Dialect describes the SQL of a driver to the SQL builders
+type Dialect = provider.Dialect
+
+// PlaceholderStyle is how a driver writes the bind parameters
+type PlaceholderStyle = provider.PlaceholderStyle
+
+// LimitStyle is how a driver limits the rows of a query
+type LimitStyle = provider.LimitStyle
+
+const (
+	// PlaceholderQuestion writes every parameter as ?
+	PlaceholderQuestion = provider.PlaceholderQuestion
+
+	// PlaceholderDollar numbers the parameters $1, $2...
+	PlaceholderDollar = provider.PlaceholderDollar
+
+	// PlaceholderColon numbers the parameters :p1, :p2...
+	PlaceholderColon = provider.PlaceholderColon
+
+	// LimitOffset writes LIMIT n OFFSET m
+	LimitOffset = provider.LimitOffset
+
+	// OffsetFetch writes OFFSET m ROWS FETCH NEXT n ROWS ONLY
+	OffsetFetch = provider.OffsetFetch
+)
+
+var (
+	// SQLiteDialect is the dialect of the sqlite and memory drivers
+	SQLiteDialect = provider.SQLiteDialect
+
+	// PostgresDialect is the dialect of the postgres driver
+	PostgresDialect = provider.PostgresDialect
+
+	// MySQLDialect is the dialect of the mysql driver
+	MySQLDialect = provider.MySQLDialect
+
+	// OracleDialect is the dialect of the oracle driver
+	OracleDialect = provider.OracleDialect
+)
+
+// drivers holds the factories registered with RegisterDriver
+var drivers = struct {
+	sync.RWMutex
+	m map[string]DriverFactory
+}{m: make(map[string]DriverFactory)}
+
+// RegisterDriver makes a driver available to NewDataProvider under name and gives its dialect to the SQL
+// builders. The built-in drivers register themselves when they are compiled in. Like sql.Register it
+// panics when name is empty, factory is nil or name is already registered
+func RegisterDriver(name string, factory DriverFactory, dialect Dialect) {
+	if name == "" || factory == nil {
+		panic("dataprovider: RegisterDriver needs a name and a factory")
+	}
+
+	drivers.Lock()
+	defer drivers.Unlock()
+
+	if _, ok := drivers.m[name]; ok {
+		panic(fmt.Sprintf("dataprovider: driver %q is already registered", name))
+	}
+
+	drivers.m[name] = factory
+	provider.RegisterDialect(name, dialect)
+}
+
+// Drivers returns the names of the registered drivers in order
+func Drivers() []string {
+	drivers.RLock()
+	defer drivers.RUnlock()
+
+	names := make([]string, 0, len(drivers.m))
+	for name := range drivers.m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// DialectFor returns the dialect of a driver, the zero Dialect for an unknown one
+func DialectFor(driver string) Dialect {
+	return provider.DialectFor(driver)
+}
+
+// driverFactory returns the factory registered for name
+func driverFactory(name string) (DriverFactory, bool) {
+	drivers.RLock()
+	defer drivers.RUnlock()
+
+	factory, ok := drivers.m[name]
+	return factory, ok
+}
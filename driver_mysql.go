@@ -0,0 +1,11 @@
+//go:build mysql
+
+package dataprovider
+
+import "github.com/inovacc/dataprovider/internal/provider"
+
+func init() {
+	RegisterDriver(MySQLDatabaseProviderName, func(options *Options) (Provider, error) {
+		return provider.NewMySQLProvider(options)
+	}, MySQLDialect)
+}
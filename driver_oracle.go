@@ -0,0 +1,11 @@
+//go:build oracle
+
+package dataprovider
+
+import "github.com/inovacc/dataprovider/internal/provider"
+
+func init() {
+	RegisterDriver(OracleDatabaseProviderName, func(options *Options) (Provider, error) {
+		return provider.NewOracleProvider(options)
+	}, OracleDialect)
+}
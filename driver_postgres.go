@@ -0,0 +1,11 @@
+//go:build postgres
+
+package dataprovider
+
+import "github.com/inovacc/dataprovider/internal/provider"
+
+func init() {
+	RegisterDriver(PostgresSQLDatabaseProviderName, func(options *Options) (Provider, error) {
+		return provider.NewPostgresSQLProvider(options)
+	}, PostgresDialect)
+}
@@ -0,0 +1,13 @@
+package dataprovider
+
+import "github.com/inovacc/dataprovider/internal/provider"
+
+func init() {
+	RegisterDriver(SQLiteDataProviderName, func(options *Options) (Provider, error) {
+		return provider.NewSQLiteProvider(options)
+	}, SQLiteDialect)
+
+	RegisterDriver(MemoryDataProviderName, func(options *Options) (Provider, error) {
+		return provider.NewMemoryProvider(options)
+	}, SQLiteDialect)
+}
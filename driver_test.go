@@ -0,0 +1,93 @@
+package dataprovider
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterDriver(t *testing.T) {
+	assert.Subset(t, Drivers(), []string{MemoryDataProviderName, SQLiteDataProviderName})
+
+	// a driver backed by the memory provider with its own dialect, registered once for -count runs
+	if !slices.Contains(Drivers(), "memorypg") {
+		RegisterDriver("memorypg", func(options *Options) (Provider, error) {
+			memory := *options
+			memory.Driver = MemoryDataProviderName
+			return NewDataProvider(&memory)
+		}, Dialect{Placeholder: PlaceholderDollar, Limit: OffsetFetch, IdentifierQuote: "`"})
+	}
+
+	assert.Contains(t, Drivers(), "memorypg")
+
+	provider, err := NewDataProvider(NewOptions(WithMemoryDB(), WithDriver("memorypg")))
+	require.NoError(t, err)
+	defer func() { _ = provider.Disconnect() }()
+	assert.NoError(t, provider.CheckAvailability())
+
+	dialect := DialectFor("memorypg")
+	assert.Equal(t, "SELECT * FROM users WHERE id = $1 AND name = $2", dialect.Rebind("SELECT * FROM users WHERE id = ? AND name = ?"))
+	assert.Equal(t, "OFFSET 20 ROWS FETCH NEXT 10 ROWS ONLY", dialect.LimitClause(10, 20))
+	assert.Equal(t, "`odd``name`", dialect.Quote("odd`name"))
+
+	assert.Panics(t, func() { RegisterDriver("memorypg", func(*Options) (Provider, error) { return nil, nil }, Dialect{}) })
+	assert.Panics(t, func() { RegisterDriver("", nil, Dialect{}) })
+
+	_, err = NewDataProvider(NewOptions(WithDriver("cassandra")))
+	assert.ErrorIs(t, err, ErrUnsupportedDriver)
+}
+
+func TestBuiltinDialects(t *testing.T) {
+	tests := []struct {
+		driver, bind, limit, quoted string
+	}{
+		{SQLiteDataProviderName, "?", "LIMIT 10 OFFSET 20", `"users"`},
+		{MemoryDataProviderName, "?", "LIMIT 10 OFFSET 20", `"users"`},
+		{PostgresSQLDatabaseProviderName, "$2", "LIMIT 10 OFFSET 20", `"users"`},
+		{MySQLDatabaseProviderName, "?", "LIMIT 10 OFFSET 20", "`users`"},
+		{OracleDatabaseProviderName, ":p2", "OFFSET 20 ROWS FETCH NEXT 10 ROWS ONLY", `"users"`},
+	}
+
+	for _, tt := range tests {
+		dialect := DialectFor(tt.driver)
+		assert.Equal(t, tt.bind, dialect.Bind(2), tt.driver)
+		assert.Equal(t, tt.limit, dialect.LimitClause(10, 20), tt.driver)
+		assert.Equal(t, tt.quoted, dialect.Quote("users"), tt.driver)
+	}
+
+	assert.Empty(t, DialectFor(OracleDatabaseProviderName).LimitClause(0, 0))
+	assert.Equal(t, "OFFSET 0 ROWS FETCH NEXT 5 ROWS ONLY", DialectFor(OracleDatabaseProviderName).LimitClause(5, 0))
+
+	// the query builder writes an explicit zero limit
+	zero := 0
+	assert.Equal(t, "LIMIT 0", DialectFor(SQLiteDataProviderName).RowsClause(&zero, nil))
+	assert.Empty(t, DialectFor(SQLiteDataProviderName).RowsClause(nil, nil))
+}
+
+func TestDriverNotCompiled(t *testing.T) {
+	for driver, tag := range map[string]string{
+		PostgresSQLDatabaseProviderName: "postgres",
+		MySQLDatabaseProviderName:       "mysql",
+		OracleDatabaseProviderName:      "oracle",
+	} {
+		if slices.Contains(Drivers(), driver) {
+			continue
+		}
+
+		options := NewOptions(WithDriver(driver), WithHost("db.local"), WithPort(1), WithUsername("app"), WithName("app"))
+		require.NoError(t, options.Validate(), driver)
+
+		var provider Provider
+		var err error
+		assert.NotPanics(t, func() { provider, err = NewDataProvider(options) }, driver)
+		assert.Nil(t, provider)
+		assert.ErrorIs(t, err, ErrDriverNotCompiled)
+
+		var notCompiled *DriverNotCompiledError
+		require.ErrorAs(t, err, &notCompiled)
+		assert.Equal(t, tag, notCompiled.Tag)
+		assert.ErrorContains(t, err, "-tags "+tag)
+	}
+}
@@ -0,0 +1,202 @@
+package migration
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	driverOracle   = "oracle"
+	driverSQLite   = "sqlite"
+	driverMySQL    = "mysql"
+	driverPostgres = "postgres"
+	driverMemory   = "memory"
+)
+
+// dialect holds the driver specific SQL used by the migration engine
+type dialect struct {
+	name string
+
+	// createTable creates the bookkeeping table, it receives the qualified table name
+	createTable string
+
+	// tableExists counts the tables matching a schema, empty for the default one, and a table name
+	tableExists string
+
+	// splitStatements tells if scripts must be executed one statement at a time
+	splitStatements bool
+
+	// newLocker creates the cross-process lock taken while migrations run
+	newLocker func(m *migrationProvider) locker
+}
+
+var dialects = map[string]dialect{
+	driverSQLite: {
+		name: driverSQLite,
+		createTable: `CREATE TABLE IF NOT EXISTS %s (
+	version INTEGER PRIMARY KEY,
+	name TEXT NOT NULL,
+	checksum TEXT NOT NULL,
+	applied_at TIMESTAMP NOT NULL
+)`,
+		tableExists: "SELECT COUNT(*) FROM pragma_table_list WHERE schema = COALESCE(NULLIF(?, ''), 'main') AND name = ?",
+		newLocker:   newSQLiteLocker,
+	},
+	driverMySQL: {
+		name: driverMySQL,
+		createTable: `CREATE TABLE IF NOT EXISTS %s (
+	version BIGINT PRIMARY KEY,
+	name VARCHAR(255) NOT NULL,
+	checksum VARCHAR(64) NOT NULL,
+	applied_at TIMESTAMP NOT NULL
+)`,
+		tableExists: `SELECT COUNT(*) FROM information_schema.TABLES
+	WHERE TABLE_SCHEMA = COALESCE(NULLIF(?, ''), DATABASE()) AND TABLE_NAME = ?`,
+		splitStatements: true,
+		newLocker:       newMySQLLocker,
+	},
+	driverPostgres: {
+		name: driverPostgres,
+		createTable: `CREATE TABLE IF NOT EXISTS %s (
+	version BIGINT PRIMARY KEY,
+	name VARCHAR(255) NOT NULL,
+	checksum VARCHAR(64) NOT NULL,
+	applied_at TIMESTAMP NOT NULL
+)`,
+		tableExists: `SELECT COUNT(*) FROM information_schema.tables
+	WHERE table_schema = COALESCE(NULLIF(?, ''), current_schema()) AND table_name = ?`,
+		newLocker: newPostgresLocker,
+	},
+	driverOracle: {
+		name: driverOracle,
+		createTable: `BEGIN
+	EXECUTE IMMEDIATE 'CREATE TABLE %s (
+		version NUMBER(19) PRIMARY KEY,
+		name VARCHAR2(255) NOT NULL,
+		checksum VARCHAR2(64) NOT NULL,
+		applied_at TIMESTAMP NOT NULL
+	)';
+EXCEPTION
+	WHEN OTHERS THEN
+		IF SQLCODE != -955 THEN
+			RAISE;
+		END IF;
+END;`,
+		tableExists:     "SELECT COUNT(*) FROM all_tables WHERE owner = NVL(UPPER(?), USER) AND table_name = UPPER(?)",
+		splitStatements: true,
+		newLocker:       newOracleLocker,
+	},
+}
+
+// dialectFor returns the dialect for the driver, memory resolves as sqlite
+func dialectFor(driver string) (dialect, error) {
+	if driver == driverMemory {
+		driver = driverSQLite
+	}
+
+	d, ok := dialects[driver]
+	if !ok {
+		return dialect{}, fmt.Errorf("migrations are not supported for driver %s", driver)
+	}
+
+	return d, nil
+}
+
+// statements splits a script into the statements that must be executed
+func (d dialect) statements(script string) []string {
+	if !d.splitStatements {
+		if strings.TrimSpace(script) == "" {
+			return nil
+		}
+		return []string{script}
+	}
+
+	if d.name == driverOracle && hasBlockTerminator(script) {
+		return splitOnBlockTerminator(script)
+	}
+
+	return splitStatements(script)
+}
+
+// hasBlockTerminator reports if the script uses a line with a single slash to end statements
+func hasBlockTerminator(script string) bool {
+	for _, line := range strings.Split(script, "\n") {
+		if strings.TrimSpace(line) == "/" {
+			return true
+		}
+	}
+	return false
+}
+
+// splitOnBlockTerminator splits an Oracle script on lines holding a single slash
+func splitOnBlockTerminator(script string) []string {
+	var (
+		stmts   []string
+		current strings.Builder
+	)
+
+	flush := func() {
+		if stmt := strings.TrimSpace(current.String()); stmt != "" {
+			stmts = append(stmts, stmt)
+		}
+		current.Reset()
+	}
+
+	for _, line := range strings.Split(script, "\n") {
+		if strings.TrimSpace(line) == "/" {
+			flush()
+			continue
+		}
+		current.WriteString(line)
+		current.WriteString("\n")
+	}
+	flush()
+
+	return stmts
+}
+
+// splitStatements splits a script on semicolons that are outside quotes and comments
+func splitStatements(script string) []string {
+	var (
+		stmts   []string
+		current strings.Builder
+	)
+
+	flush := func() {
+		if stmt := strings.TrimSpace(current.String()); stmt != "" {
+			stmts = append(stmts, stmt)
+		}
+		current.Reset()
+	}
+
+	runes := []rune(script)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+
+		switch {
+		case r == '\'' || r == '"' || r == '`':
+			current.WriteRune(r)
+			for i++; i < len(runes); i++ {
+				current.WriteRune(runes[i])
+				if runes[i] == r {
+					break
+				}
+			}
+		case r == '-' && i+1 < len(runes) && runes[i+1] == '-':
+			for ; i < len(runes) && runes[i] != '\n'; i++ {
+			}
+			current.WriteRune('\n')
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			for i += 2; i+1 < len(runes) && (runes[i] != '*' || runes[i+1] != '/'); i++ {
+			}
+			i++
+		case r == ';':
+			flush()
+		default:
+			current.WriteRune(r)
+		}
+	}
+	flush()
+
+	return stmts
+}
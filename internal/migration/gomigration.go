@@ -0,0 +1,112 @@
+package migration
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// GoMigrationFunc applies or reverts a migration written in Go inside the migration transaction
+type GoMigrationFunc func(ctx context.Context, tx *sqlx.Tx) error
+
+// GoMigration is a migration written in Go, it shares the version sequence with the SQL files
+type GoMigration struct {
+	Version int
+	Name    string
+	Up      GoMigrationFunc
+	Down    GoMigrationFunc
+}
+
+// validate checks that the Go migration can be registered
+func (g GoMigration) validate() error {
+	if g.Version <= 0 {
+		return fmt.Errorf("go migration %q: invalid version %d", g.Name, g.Version)
+	}
+
+	if g.Name == "" {
+		return fmt.Errorf("go migration %d: name is empty", g.Version)
+	}
+
+	if g.Up == nil {
+		return fmt.Errorf("go migration %d (%s): up function is nil", g.Version, g.Name)
+	}
+
+	return nil
+}
+
+// checksum identifies a Go migration by version and name since its code cannot be hashed
+func (g GoMigration) checksum() string {
+	sum := sha256.Sum256(fmt.Appendf(nil, "go:%d_%s", g.Version, g.Name))
+	return hex.EncodeToString(sum[:])
+}
+
+// goRegistry holds the Go migrations of an engine, it is shared with the engines returned by WithContext
+type goRegistry struct {
+	mu         sync.Mutex
+	migrations []GoMigration
+}
+
+// list returns a copy of the registered Go migrations
+func (r *goRegistry) list() []GoMigration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return slices.Clone(r.migrations)
+}
+
+// Register adds a Go migration to the engine and the engines sharing its Go migrations
+func (m *migrationProvider) Register(version int, name string, up, down GoMigrationFunc) error {
+	g := GoMigration{Version: version, Name: name, Up: up, Down: down}
+	if err := g.validate(); err != nil {
+		return err
+	}
+
+	m.goMigrations.mu.Lock()
+	defer m.goMigrations.mu.Unlock()
+
+	for _, registered := range m.goMigrations.migrations {
+		if registered.Version == version {
+			return fmt.Errorf("go migration %d is already registered as %q", version, registered.Name)
+		}
+	}
+
+	m.goMigrations.migrations = append(m.goMigrations.migrations, g)
+	return nil
+}
+
+// mergeGoMigrations interleaves the Go migrations with the file scripts by version
+func mergeGoMigrations(scripts []*script, goMigrations []GoMigration) ([]*script, error) {
+	byVersion := make(map[int]*script, len(scripts))
+	for _, s := range scripts {
+		byVersion[s.version] = s
+	}
+
+	for _, g := range goMigrations {
+		if err := g.validate(); err != nil {
+			return nil, err
+		}
+
+		if s, ok := byVersion[g.Version]; ok {
+			if s.upFunc != nil {
+				return nil, fmt.Errorf("go migration %d is registered more than once", g.Version)
+			}
+			return nil, fmt.Errorf("go migration %d (%s) conflicts with migration file %d (%s)", g.Version, g.Name, s.version, s.name)
+		}
+
+		s := &script{version: g.Version, name: g.Name, upFunc: g.Up, downFunc: g.Down, goChecksum: g.checksum()}
+		byVersion[g.Version] = s
+		scripts = append(scripts, s)
+	}
+
+	sort.Slice(scripts, func(i, j int) bool {
+		return scripts[i].version < scripts[j].version
+	})
+
+	return scripts, nil
+}
@@ -0,0 +1,413 @@
+package migration
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// lockPollInterval is the time to wait between two attempts to take the migration lock
+const lockPollInterval = 100 * time.Millisecond
+
+// DefaultStaleLockAfter is the age after which the SQLite lock row is reported as stale
+const DefaultStaleLockAfter = 10 * time.Minute
+
+// ErrLocked is returned when the migration lock could not be taken before the context was done
+var ErrLocked = errors.New("migrations are locked")
+
+// ErrStaleLock is returned when the SQLite lock row is older than Options.StaleLockAfter, its holder
+// probably crashed and nothing else removes the row
+var ErrStaleLock = errors.New("migration lock is stale")
+
+// LockError reports who holds the migration lock when waiting for it failed
+type LockError struct {
+	Holder string
+	Err    error
+}
+
+func (e *LockError) Error() string {
+	return fmt.Sprintf("migration lock is held by %s: %v", e.Holder, e.Err)
+}
+
+func (e *LockError) Unwrap() []error {
+	return []error{ErrLocked, e.Err}
+}
+
+// locker takes the cross-process migration lock of a dialect
+type locker interface {
+	// tryLock takes the lock without waiting, when the lock is taken by somebody else it
+	// returns false and a description of the holder
+	tryLock(ctx context.Context) (bool, string, error)
+
+	// release frees the lock when it is held and the resources used to take it
+	release(ctx context.Context) error
+}
+
+// withLock runs fn while holding the migration lock, waiting for it until the context is done
+func (m *migrationProvider) withLock(fn func() error) (err error) {
+	l := m.dialect.newLocker(m)
+
+	defer func() {
+		// release even when the context was canceled while fn was running
+		if releaseErr := l.release(context.WithoutCancel(m.options.Context)); releaseErr != nil {
+			err = errors.Join(err, releaseErr)
+		}
+	}()
+
+	if err = acquire(m.options.Context, l); err != nil {
+		return err
+	}
+
+	return fn()
+}
+
+// acquire polls the locker until it takes the lock or the context is done
+func acquire(ctx context.Context, l locker) error {
+	for {
+		ok, holder, err := l.tryLock(ctx)
+		if err != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return &LockError{Holder: "unknown", Err: ctxErr}
+			}
+			return err
+		}
+
+		if ok {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return &LockError{Holder: holder, Err: ctx.Err()}
+		case <-time.After(lockPollInterval):
+		}
+	}
+}
+
+// unknownHolder describes the holder of a lock that could not be read, the error is kept in the description
+// because the lock is still waited for, missing privileges on the server views show up in the LockError
+func unknownHolder(err error) string {
+	if errors.Is(err, sql.ErrNoRows) {
+		return "nobody, the lock was just released"
+	}
+	return fmt.Sprintf("unknown (reading the holder failed: %v)", err)
+}
+
+// lockKey derives a stable lock key from the bookkeeping table name
+func (m *migrationProvider) lockKey() int64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(m.tableName()))
+	return int64(h.Sum64() >> 1)
+}
+
+// lockName is the name of the named lock used by MySQL and Oracle
+func (m *migrationProvider) lockName() string {
+	name := "dataprovider:" + m.tableName()
+	if len(name) > 64 {
+		name = name[:64]
+	}
+	return name
+}
+
+// lockOwner identifies this engine as the holder of a lock row
+func lockOwner() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+
+	suffix := make([]byte, 4)
+	_, _ = rand.Read(suffix)
+
+	return fmt.Sprintf("%s:%d:%s", host, os.Getpid(), hex.EncodeToString(suffix))
+}
+
+// sessionLocker keeps the connection that owns a session level lock
+type sessionLocker struct {
+	db   *sqlx.DB
+	conn *sqlx.Conn
+	held bool
+}
+
+// connection returns the dedicated connection, opening it on first use
+func (l *sessionLocker) connection(ctx context.Context) (*sqlx.Conn, error) {
+	if l.conn != nil {
+		return l.conn, nil
+	}
+
+	conn, err := l.db.Connx(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	l.conn = conn
+	return conn, nil
+}
+
+// close returns the dedicated connection to the pool
+func (l *sessionLocker) close(err error) error {
+	if l.conn == nil {
+		return err
+	}
+
+	closeErr := l.conn.Close()
+	l.conn, l.held = nil, false
+	return errors.Join(err, closeErr)
+}
+
+// postgresLocker uses a session level advisory lock
+type postgresLocker struct {
+	sessionLocker
+	key int64
+}
+
+func newPostgresLocker(m *migrationProvider) locker {
+	return &postgresLocker{sessionLocker: sessionLocker{db: m.db}, key: m.lockKey()}
+}
+
+func (l *postgresLocker) tryLock(ctx context.Context) (bool, string, error) {
+	conn, err := l.connection(ctx)
+	if err != nil {
+		return false, "", err
+	}
+
+	if err = conn.GetContext(ctx, &l.held, "SELECT pg_try_advisory_lock($1)", l.key); err != nil || l.held {
+		return l.held, "", err
+	}
+
+	var holder struct {
+		PID         int    `db:"pid"`
+		Application string `db:"application_name"`
+		Address     string `db:"client_addr"`
+	}
+	query := `SELECT l.pid, COALESCE(a.application_name, '') AS application_name,
+		COALESCE(host(a.client_addr), 'local') AS client_addr
+		FROM pg_locks l LEFT JOIN pg_stat_activity a ON a.pid = l.pid
+		WHERE l.locktype = 'advisory' AND l.granted AND l.objsubid = 1
+		AND ((l.classid::bigint << 32) | l.objid::bigint) = $1
+		LIMIT 1`
+	if err = conn.GetContext(ctx, &holder, query, l.key); err != nil {
+		return false, unknownHolder(err), nil
+	}
+
+	return false, fmt.Sprintf("pid %d (%s from %s)", holder.PID, holder.Application, holder.Address), nil
+}
+
+func (l *postgresLocker) release(ctx context.Context) error {
+	var err error
+	if l.held {
+		_, err = l.conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", l.key)
+	}
+	return l.close(err)
+}
+
+// mysqlLocker uses a named lock taken with GET_LOCK
+type mysqlLocker struct {
+	sessionLocker
+	name string
+}
+
+func newMySQLLocker(m *migrationProvider) locker {
+	return &mysqlLocker{sessionLocker: sessionLocker{db: m.db}, name: m.lockName()}
+}
+
+func (l *mysqlLocker) tryLock(ctx context.Context) (bool, string, error) {
+	conn, err := l.connection(ctx)
+	if err != nil {
+		return false, "", err
+	}
+
+	var acquired sql.NullInt64
+	if err = conn.GetContext(ctx, &acquired, "SELECT GET_LOCK(?, 0)", l.name); err != nil {
+		return false, "", err
+	}
+
+	if l.held = acquired.Int64 == 1; l.held {
+		return true, "", nil
+	}
+
+	var holder struct {
+		ID   int64  `db:"ID"`
+		User string `db:"USER"`
+		Host string `db:"HOST"`
+	}
+	query := `SELECT ID, USER, HOST FROM information_schema.PROCESSLIST WHERE ID = IS_USED_LOCK(?)`
+	if err = conn.GetContext(ctx, &holder, query, l.name); err != nil {
+		return false, unknownHolder(err), nil
+	}
+
+	return false, fmt.Sprintf("connection %d (%s@%s)", holder.ID, holder.User, holder.Host), nil
+}
+
+func (l *mysqlLocker) release(ctx context.Context) error {
+	var err error
+	if l.held {
+		_, err = l.conn.ExecContext(ctx, "SELECT RELEASE_LOCK(?)", l.name)
+	}
+	return l.close(err)
+}
+
+// oracleLocker uses a user lock taken with DBMS_LOCK
+type oracleLocker struct {
+	sessionLocker
+	name string
+}
+
+func newOracleLocker(m *migrationProvider) locker {
+	return &oracleLocker{sessionLocker: sessionLocker{db: m.db}, name: m.lockName()}
+}
+
+func (l *oracleLocker) tryLock(ctx context.Context) (bool, string, error) {
+	conn, err := l.connection(ctx)
+	if err != nil {
+		return false, "", err
+	}
+
+	var result int64
+	query := `DECLARE
+	handle VARCHAR2(128);
+BEGIN
+	DBMS_LOCK.ALLOCATE_UNIQUE(:1, handle);
+	:2 := DBMS_LOCK.REQUEST(handle, DBMS_LOCK.X_MODE, 0, FALSE);
+END;`
+	if _, err = conn.ExecContext(ctx, query, l.name, sql.Out{Dest: &result}); err != nil {
+		return false, "", err
+	}
+
+	// 0 is success and 4 means this session already owns the lock
+	switch result {
+	case 0, 4:
+		l.held = true
+		return true, "", nil
+	case 1:
+	default:
+		return false, "", fmt.Errorf("DBMS_LOCK.REQUEST returned %d", result)
+	}
+
+	var holder struct {
+		SID      int64  `db:"SID"`
+		Username string `db:"USERNAME"`
+		Machine  string `db:"MACHINE"`
+	}
+	holderQuery := `SELECT s.sid, NVL(s.username, ' ') AS username, NVL(s.machine, ' ') AS machine
+		FROM v$lock k JOIN v$session s ON s.sid = k.sid
+		JOIN sys.dbms_lock_allocated a ON a.lockid = k.id1
+		WHERE k.type = 'UL' AND a.name = :1`
+	if err = conn.GetContext(ctx, &holder, holderQuery, l.name); err != nil {
+		return false, unknownHolder(err), nil
+	}
+
+	return false, fmt.Sprintf("session %d (%s@%s)", holder.SID, holder.Username, holder.Machine), nil
+}
+
+func (l *oracleLocker) release(ctx context.Context) error {
+	var err error
+	if l.held {
+		query := `DECLARE
+	handle VARCHAR2(128);
+	result INTEGER;
+BEGIN
+	DBMS_LOCK.ALLOCATE_UNIQUE(:1, handle);
+	result := DBMS_LOCK.RELEASE(handle);
+END;`
+		_, err = l.conn.ExecContext(ctx, query, l.name)
+	}
+	return l.close(err)
+}
+
+// sqliteLocker stores the lock in a single row table, it does not keep a connection busy so it works
+// with the single connection pool of the SQLite provider
+type sqliteLocker struct {
+	db         *sqlx.DB
+	table      string
+	owner      string
+	staleAfter time.Duration
+	held       bool
+}
+
+func newSQLiteLocker(m *migrationProvider) locker {
+	staleAfter := m.options.StaleLockAfter
+	if staleAfter == 0 {
+		staleAfter = DefaultStaleLockAfter
+	}
+
+	return &sqliteLocker{db: m.db, table: m.tableName() + "_lock", owner: lockOwner(), staleAfter: staleAfter}
+}
+
+func (l *sqliteLocker) tryLock(ctx context.Context) (bool, string, error) {
+	create := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	id INTEGER PRIMARY KEY CHECK (id = 1),
+	holder TEXT NOT NULL,
+	acquired_at TIMESTAMP NOT NULL
+)`, l.table)
+	if _, err := l.db.ExecContext(ctx, create); err != nil {
+		return busy(err)
+	}
+
+	insert := fmt.Sprintf("INSERT INTO %s (id, holder, acquired_at) VALUES (1, ?, ?) ON CONFLICT (id) DO NOTHING", l.table)
+	result, err := l.db.ExecContext(ctx, insert, l.owner, time.Now().UTC())
+	if err != nil {
+		return busy(err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, "", err
+	}
+
+	if l.held = rows == 1; l.held {
+		return true, "", nil
+	}
+
+	var holder struct {
+		Holder     string    `db:"holder"`
+		AcquiredAt time.Time `db:"acquired_at"`
+	}
+	err = l.db.GetContext(ctx, &holder, fmt.Sprintf("SELECT holder, acquired_at FROM %s WHERE id = 1", l.table))
+	if errors.Is(err, sql.ErrNoRows) {
+		// released since the insert, the next attempt takes it
+		return false, unknownHolder(err), nil
+	}
+	if err != nil {
+		return busy(err)
+	}
+
+	description := fmt.Sprintf("%s since %s", holder.Holder, holder.AcquiredAt.Format(time.RFC3339))
+	if l.staleAfter > 0 && time.Since(holder.AcquiredAt) > l.staleAfter {
+		return false, description, &LockError{
+			Holder: description,
+			Err:    fmt.Errorf("%w, delete the row of %s if its holder is gone", ErrStaleLock, l.table),
+		}
+	}
+
+	return false, description, nil
+}
+
+func (l *sqliteLocker) release(ctx context.Context) error {
+	if !l.held {
+		return nil
+	}
+
+	_, err := l.db.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE id = 1 AND holder = ?", l.table), l.owner)
+	l.held = err != nil
+	return err
+}
+
+// busy turns SQLITE_BUSY and SQLITE_LOCKED errors into a lock held by another connection
+func busy(err error) (bool, string, error) {
+	var coded interface{ Code() int }
+	if errors.As(err, &coded) {
+		if code := coded.Code() & 0xff; code == 5 || code == 6 {
+			return false, "another connection", nil
+		}
+	}
+	return false, "", err
+}
@@ -1,28 +1,476 @@
 package migration
 
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/spf13/afero"
+)
+
+// DefaultTableName is the name of the table that records the applied migrations
+const DefaultTableName = "schema_migrations"
+
+// ErrNoMigrationsPath is returned when the engine has no directory to load migrations from
+var ErrNoMigrationsPath = errors.New("migrations path is not set")
+
+// ErrMissingDownScript is returned when a migration that must be reverted has no down script
+var ErrMissingDownScript = errors.New("down script is missing")
+
+// ErrVersionNotApplied is returned when the revert target is not a recorded version
+var ErrVersionNotApplied = errors.New("version is not applied")
+
 type Migration interface {
+	// Validate checks the migrations found in the given directory, an empty path uses the
+	// configured one, and reports drift between applied migrations and their sources
 	Validate(string) error
+
+	// Migrate applies every pending migration in ascending version order
 	Migrate() error
+
+	// Revert rolls back the last applied migration
 	Revert() error
+
+	// RevertTo rolls back applied migrations in reverse order until targetVersion is
+	// the latest recorded version and returns the reverted versions, 0 reverts everything
+	RevertTo(targetVersion int) ([]int, error)
+
+	// Register adds a Go migration that is applied in version order with the SQL files
+	Register(version int, name string, up, down GoMigrationFunc) error
+
+	// WithContext returns an engine running with ctx that shares the Go migrations of this one
+	WithContext(ctx context.Context) Migration
+
+	// Plan returns the pending migrations with the statements Migrate would execute, nothing is executed
+	Plan() ([]PlannedMigration, error)
+
+	// Status reports every known version as applied, pending or missing
+	Status() ([]MigrationStatus, error)
 }
 
-type migrationProvider struct{}
+// Source describes where migrations are loaded from
+type Source struct {
+	// Path is the directory that holds the numbered up/down SQL files, files in its
+	// <Path>/<dialect> subdirectory take precedence over the shared ones
+	Path string
+
+	// Fs is the filesystem migrations are read from, it defaults to the OS filesystem
+	Fs afero.Fs
 
-func (m *migrationProvider) Validate(path string) error {
-	// TODO implement me
-	panic("implement me")
+	// GoMigrations are applied together with the SQL files
+	GoMigrations []GoMigration
 }
 
+// Options configures the migration engine
+type Options struct {
+	Source
+
+	// Driver is the provider driver name, it selects the SQL dialect
+	Driver string
+
+	// Schema qualifies the bookkeeping table when it is not empty
+	Schema string
+
+	// TablePrefix is prepended to the bookkeeping table name
+	TablePrefix string
+
+	Context context.Context
+
+	// StaleLockAfter is the age after which the SQLite lock row is reported as stale instead of waited
+	// for, DefaultStaleLockAfter when zero and never when negative
+	StaleLockAfter time.Duration
+
+	// Check is called before every operation and stops it with its error, providers use it to refuse
+	// running once they are disconnected
+	Check func() error
+}
+
+type migrationProvider struct {
+	db           *sqlx.DB
+	fs           afero.Fs
+	dialect      dialect
+	options      Options
+	goMigrations *goRegistry
+	err          error
+}
+
+// NewMigration creates a migration engine that runs against db
+func NewMigration(db *sqlx.DB, options Options) Migration {
+	if options.Context == nil {
+		options.Context = context.Background()
+	}
+
+	if options.Fs == nil {
+		options.Fs = afero.NewOsFs()
+	}
+
+	d, err := dialectFor(options.Driver)
+
+	return &migrationProvider{
+		db:           db,
+		fs:           options.Fs,
+		dialect:      d,
+		options:      options,
+		goMigrations: &goRegistry{migrations: slices.Clone(options.GoMigrations)},
+		err:          err,
+	}
+}
+
+// WithContext returns a copy of the engine running with ctx, Go migrations registered on either one are
+// seen by both
+func (m *migrationProvider) WithContext(ctx context.Context) Migration {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	engine := *m
+	engine.options.Context = ctx
+	return &engine
+}
+
+// tableName returns the qualified name of the bookkeeping table
+func (m *migrationProvider) tableName() string {
+	name := m.options.TablePrefix + DefaultTableName
+	if m.options.Schema != "" {
+		return m.options.Schema + "." + name
+	}
+	return name
+}
+
+// ready returns the error stopping every operation of the engine, if any
+func (m *migrationProvider) ready() error {
+	if m.err != nil {
+		return m.err
+	}
+
+	if m.options.Check != nil {
+		return m.options.Check()
+	}
+
+	return nil
+}
+
+// Migrate applies every pending migration in ascending version order
 func (m *migrationProvider) Migrate() error {
-	// TODO implement me
-	panic("implement me")
+	if err := m.ready(); err != nil {
+		return err
+	}
+
+	// an engine without migrations fails before taking the lock, which would create the lock table
+	if m.options.Path == "" && len(m.goMigrations.list()) == 0 {
+		return ErrNoMigrationsPath
+	}
+
+	return m.withLock(m.migrate)
 }
 
+// migrate applies the pending migrations, the caller holds the migration lock
+func (m *migrationProvider) migrate() error {
+	scripts, err := m.load(m.options.Path)
+	if err != nil {
+		return err
+	}
+
+	if err = checkScripts(scripts); err != nil {
+		return err
+	}
+
+	if err = m.ensureTable(); err != nil {
+		return err
+	}
+
+	applied, err := m.appliedVersions()
+	if err != nil {
+		return err
+	}
+
+	for _, s := range scripts {
+		if _, ok := applied[s.version]; ok {
+			continue
+		}
+
+		if err = m.apply(s); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Revert rolls back the last applied migration
 func (m *migrationProvider) Revert() error {
-	// TODO implement me
-	panic("implement me")
+	if err := m.ready(); err != nil {
+		return err
+	}
+
+	return m.withLock(func() error {
+		if err := m.ensureTable(); err != nil {
+			return err
+		}
+
+		applied, err := m.appliedVersions()
+		if err != nil {
+			return err
+		}
+
+		versions := sortedVersions(applied)
+		if len(versions) == 0 {
+			return nil
+		}
+
+		target := 0
+		if len(versions) > 1 {
+			target = versions[len(versions)-2]
+		}
+
+		_, err = m.revertTo(target)
+		return err
+	})
 }
 
-func NewMigration() Migration {
-	return &migrationProvider{}
+// RevertTo rolls back applied migrations in reverse order until targetVersion is the latest recorded version
+func (m *migrationProvider) RevertTo(targetVersion int) ([]int, error) {
+	if targetVersion < 0 {
+		return nil, fmt.Errorf("invalid target version %d", targetVersion)
+	}
+
+	if err := m.ready(); err != nil {
+		return nil, err
+	}
+
+	var reverted []int
+	err := m.withLock(func() error {
+		var err error
+		reverted, err = m.revertTo(targetVersion)
+		return err
+	})
+
+	return reverted, err
+}
+
+// revertTo rolls back the applied migrations above targetVersion, the caller holds the migration lock
+func (m *migrationProvider) revertTo(targetVersion int) ([]int, error) {
+	scripts, err := m.load(m.options.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = m.ensureTable(); err != nil {
+		return nil, err
+	}
+
+	applied, err := m.appliedVersions()
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := applied[targetVersion]; targetVersion != 0 && !ok {
+		return nil, fmt.Errorf("target %d: %w", targetVersion, ErrVersionNotApplied)
+	}
+
+	byVersion := make(map[int]*script, len(scripts))
+	for _, s := range scripts {
+		byVersion[s.version] = s
+	}
+
+	// check every down script before touching the database
+	versions := sortedVersions(applied)
+	var pending []*script
+	for i := len(versions) - 1; i >= 0 && versions[i] > targetVersion; i-- {
+		s, ok := byVersion[versions[i]]
+		if !ok {
+			return nil, fmt.Errorf("migration %d: %w", versions[i], ErrMissingDownScript)
+		}
+
+		if !s.hasDown() {
+			return nil, fmt.Errorf("migration %d (%s): %w", s.version, s.name, ErrMissingDownScript)
+		}
+
+		pending = append(pending, s)
+	}
+
+	reverted := make([]int, 0, len(pending))
+	for _, s := range pending {
+		if err = m.rollback(s); err != nil {
+			return reverted, err
+		}
+		reverted = append(reverted, s.version)
+	}
+
+	return reverted, nil
+}
+
+// load reads the migration scripts from path and merges them with the Go migrations
+func (m *migrationProvider) load(path string) ([]*script, error) {
+	if err := m.ready(); err != nil {
+		return nil, err
+	}
+
+	if path == "" {
+		goMigrations := m.goMigrations.list()
+		if len(goMigrations) == 0 {
+			return nil, ErrNoMigrationsPath
+		}
+		return mergeGoMigrations(nil, goMigrations)
+	}
+
+	scripts, err := loadScripts(m.fs, path, m.dialect.name)
+	if err != nil {
+		return nil, err
+	}
+
+	return mergeGoMigrations(scripts, m.goMigrations.list())
+}
+
+// checkScripts verifies that every migration can be applied
+func checkScripts(scripts []*script) error {
+	for _, s := range scripts {
+		if !s.hasUp() {
+			return fmt.Errorf("migration %d (%s) has no up file", s.version, s.name)
+		}
+	}
+	return nil
+}
+
+// ensureTable creates the bookkeeping table when it does not exist
+func (m *migrationProvider) ensureTable() error {
+	_, err := m.db.ExecContext(m.options.Context, fmt.Sprintf(m.dialect.createTable, m.tableName()))
+	return err
+}
+
+// appliedVersions returns the versions recorded in the bookkeeping table
+func (m *migrationProvider) appliedVersions() (map[int]struct{}, error) {
+	var versions []int
+	query := fmt.Sprintf("SELECT version FROM %s", m.tableName())
+	if err := m.db.SelectContext(m.options.Context, &versions, query); err != nil {
+		return nil, err
+	}
+
+	applied := make(map[int]struct{}, len(versions))
+	for _, v := range versions {
+		applied[v] = struct{}{}
+	}
+
+	return applied, nil
+}
+
+// appliedMigrations returns the records of the bookkeeping table ordered by version. The columns are
+// scanned by position because Oracle reports them in uppercase, and applied_at goes through a timestamp
+// because MySQL returns it as bytes without parseTime
+func (m *migrationProvider) appliedMigrations() ([]AppliedMigration, error) {
+	query := fmt.Sprintf("SELECT version, name, checksum, applied_at FROM %s ORDER BY version", m.tableName())
+	rows, err := m.db.QueryContext(m.options.Context, query)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var records []AppliedMigration
+	for rows.Next() {
+		var (
+			record    AppliedMigration
+			appliedAt timestamp
+		)
+		if err = rows.Scan(&record.Version, &record.Name, &record.Checksum, &appliedAt); err != nil {
+			return nil, err
+		}
+
+		record.AppliedAt = time.Time(appliedAt)
+		records = append(records, record)
+	}
+
+	return records, rows.Err()
+}
+
+// recordedMigrations returns the records of the bookkeeping table, none when the table does not exist,
+// without creating it
+func (m *migrationProvider) recordedMigrations() ([]AppliedMigration, error) {
+	var count int
+	query := m.db.Rebind(m.dialect.tableExists)
+	if err := m.db.GetContext(m.options.Context, &count, query, m.options.Schema, m.options.TablePrefix+DefaultTableName); err != nil {
+		return nil, err
+	}
+
+	if count == 0 {
+		return nil, nil
+	}
+
+	return m.appliedMigrations()
+}
+
+// sortedVersions returns the applied versions in ascending order
+func sortedVersions(applied map[int]struct{}) []int {
+	versions := make([]int, 0, len(applied))
+	for v := range applied {
+		versions = append(versions, v)
+	}
+	sort.Ints(versions)
+	return versions
+}
+
+// apply runs the up script of s and records it inside a single transaction
+func (m *migrationProvider) apply(s *script) error {
+	return m.inTx(func(tx *sqlx.Tx) error {
+		if err := m.run(tx, s.up, s.upFunc); err != nil {
+			return fmt.Errorf("migration %d (%s): %w", s.version, s.name, err)
+		}
+
+		query := tx.Rebind(fmt.Sprintf("INSERT INTO %s (version, name, checksum, applied_at) VALUES (?, ?, ?, ?)", m.tableName()))
+		_, err := tx.ExecContext(m.options.Context, query, s.version, s.name, s.checksum(), time.Now().UTC())
+		return err
+	})
+}
+
+// rollback runs the down script of s and removes its record inside a single transaction
+func (m *migrationProvider) rollback(s *script) error {
+	return m.inTx(func(tx *sqlx.Tx) error {
+		if err := m.run(tx, s.down, s.downFunc); err != nil {
+			return fmt.Errorf("revert %d (%s): %w", s.version, s.name, err)
+		}
+
+		query := tx.Rebind(fmt.Sprintf("DELETE FROM %s WHERE version = ?", m.tableName()))
+		_, err := tx.ExecContext(m.options.Context, query, s.version)
+		return err
+	})
+}
+
+// run calls fn when the migration is written in Go, otherwise it executes the SQL script
+func (m *migrationProvider) run(tx *sqlx.Tx, script string, fn GoMigrationFunc) error {
+	if fn != nil {
+		return fn(m.options.Context, tx)
+	}
+
+	return m.exec(tx, script)
+}
+
+// exec runs every statement of the script in the transaction
+func (m *migrationProvider) exec(tx *sqlx.Tx, script string) error {
+	for _, stmt := range m.dialect.statements(script) {
+		if _, err := tx.ExecContext(m.options.Context, stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// inTx runs fn inside a transaction, committing on success and rolling back on error
+func (m *migrationProvider) inTx(fn func(tx *sqlx.Tx) error) error {
+	tx, err := m.db.BeginTxx(m.options.Context, nil)
+	if err != nil {
+		return err
+	}
+
+	if err = fn(tx); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil && !errors.Is(rbErr, sql.ErrTxDone) {
+			return errors.Join(err, rbErr)
+		}
+		return err
+	}
+
+	return tx.Commit()
 }
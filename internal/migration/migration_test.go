@@ -0,0 +1,630 @@
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+)
+
+func newTestDB(t *testing.T) *sqlx.DB {
+	t.Helper()
+
+	db, err := sqlx.Connect("sqlite", filepath.Join(t.TempDir(), "migration.sqlite3"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	return db
+}
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+}
+
+func tableExists(t *testing.T, db *sqlx.DB, table string) bool {
+	t.Helper()
+
+	var count int
+	require.NoError(t, db.Get(&count, "SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?", table))
+	return count > 0
+}
+
+func TestMigrate(t *testing.T) {
+	db := newTestDB(t)
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"0001_create_users.up.sql":    "CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT);",
+		"0001_create_users.down.sql":  "DROP TABLE users;",
+		"0002_create_cities.up.sql":   "CREATE TABLE cities (id INTEGER PRIMARY KEY); CREATE INDEX idx_cities ON cities (id);",
+		"0002_create_cities.down.sql": "DROP TABLE cities;",
+		"README.md":                   "ignored",
+	})
+
+	m := NewMigration(db, Options{Driver: driverSQLite, Source: Source{Path: dir}})
+	require.NoError(t, m.Migrate())
+
+	assert.True(t, tableExists(t, db, "users"))
+	assert.True(t, tableExists(t, db, "cities"))
+
+	var versions []int
+	require.NoError(t, db.Select(&versions, "SELECT version FROM schema_migrations ORDER BY version"))
+	assert.Equal(t, []int{1, 2}, versions)
+
+	// running again is a no-op
+	require.NoError(t, m.Migrate())
+
+	require.NoError(t, m.Revert())
+	assert.False(t, tableExists(t, db, "cities"))
+	assert.True(t, tableExists(t, db, "users"))
+}
+
+func TestMigrateFailureRollsBack(t *testing.T) {
+	db := newTestDB(t)
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"0001_create_users.up.sql": "CREATE TABLE users (id INTEGER PRIMARY KEY);",
+		"0002_broken.up.sql":       "CREATE TABLE broken (id INTEGER PRIMARY KEY); INSERT INTO missing VALUES (1);",
+	})
+
+	m := NewMigration(db, Options{Driver: driverMemory, Source: Source{Path: dir}, TablePrefix: "app_"})
+	err := m.Migrate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "migration 2 (broken)")
+
+	assert.True(t, tableExists(t, db, "users"))
+	assert.False(t, tableExists(t, db, "broken"))
+
+	var versions []int
+	require.NoError(t, db.Select(&versions, "SELECT version FROM app_schema_migrations"))
+	assert.Equal(t, []int{1}, versions)
+}
+
+func TestCheck(t *testing.T) {
+	db := newTestDB(t)
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"0001_create_users.up.sql": "CREATE TABLE users (id INTEGER PRIMARY KEY);"})
+
+	closed := errors.New("closed")
+	m := NewMigration(db, Options{Driver: driverSQLite, Source: Source{Path: dir}, Check: func() error { return closed }})
+
+	assert.ErrorIs(t, m.Migrate(), closed)
+	assert.ErrorIs(t, m.Revert(), closed)
+	assert.ErrorIs(t, m.Validate(""), closed)
+	_, err := m.RevertTo(0)
+	assert.ErrorIs(t, err, closed)
+	_, err = m.Plan()
+	assert.ErrorIs(t, err, closed)
+	_, err = m.Status()
+	assert.ErrorIs(t, err, closed)
+
+	assert.False(t, tableExists(t, db, "users"))
+}
+
+func TestValidate(t *testing.T) {
+	db := newTestDB(t)
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"0001_create_users.down.sql": "DROP TABLE users;",
+	})
+
+	m := NewMigration(db, Options{Driver: driverSQLite})
+	assert.ErrorIs(t, m.Validate(""), ErrNoMigrationsPath)
+	assert.ErrorContains(t, m.Validate(dir), "has no up file")
+
+	m = NewMigration(db, Options{Driver: "sqlserver", Source: Source{Path: dir}})
+	assert.ErrorContains(t, m.Migrate(), "not supported")
+}
+
+func TestSplitStatements(t *testing.T) {
+	script := `-- create; things
+CREATE TABLE a (name TEXT DEFAULT 'x;y');
+/* block; comment */
+INSERT INTO a VALUES ("q;");
+`
+
+	stmts := splitStatements(script)
+	assert.Equal(t, []string{
+		"CREATE TABLE a (name TEXT DEFAULT 'x;y')",
+		`INSERT INTO a VALUES ("q;")`,
+	}, stmts)
+
+	oracle := dialects[driverOracle]
+	stmts = oracle.statements("BEGIN\n  NULL;\nEND;\n/\nCREATE TABLE b (id NUMBER)\n/\n")
+	assert.Equal(t, []string{"BEGIN\n  NULL;\nEND;", "CREATE TABLE b (id NUMBER)"}, stmts)
+}
+
+func TestRevertTo(t *testing.T) {
+	db := newTestDB(t)
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"0001_create_users.up.sql":    "CREATE TABLE users (id INTEGER PRIMARY KEY);",
+		"0001_create_users.down.sql":  "DROP TABLE users;",
+		"0002_create_cities.up.sql":   "CREATE TABLE cities (id INTEGER PRIMARY KEY);",
+		"0002_create_cities.down.sql": "DROP TABLE cities;",
+		"0003_create_towns.up.sql":    "CREATE TABLE towns (id INTEGER PRIMARY KEY);",
+		"0003_create_towns.down.sql":  "DROP TABLE towns;",
+	})
+
+	m := NewMigration(db, Options{Driver: driverSQLite, Source: Source{Path: dir}})
+	require.NoError(t, m.Migrate())
+
+	_, err := m.RevertTo(7)
+	assert.ErrorIs(t, err, ErrVersionNotApplied)
+
+	reverted, err := m.RevertTo(1)
+	require.NoError(t, err)
+	assert.Equal(t, []int{3, 2}, reverted)
+	assert.True(t, tableExists(t, db, "users"))
+	assert.False(t, tableExists(t, db, "cities"))
+	assert.False(t, tableExists(t, db, "towns"))
+
+	reverted, err = m.RevertTo(1)
+	require.NoError(t, err)
+	assert.Empty(t, reverted)
+
+	reverted, err = m.RevertTo(0)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1}, reverted)
+	assert.False(t, tableExists(t, db, "users"))
+}
+
+func TestRevertToMissingDownScript(t *testing.T) {
+	db := newTestDB(t)
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"0001_create_users.up.sql":   "CREATE TABLE users (id INTEGER PRIMARY KEY);",
+		"0001_create_users.down.sql": "DROP TABLE users;",
+		"0002_seed_users.up.sql":     "INSERT INTO users (id) VALUES (1);",
+		"0003_create_towns.up.sql":   "CREATE TABLE towns (id INTEGER PRIMARY KEY);",
+		"0003_create_towns.down.sql": "DROP TABLE towns;",
+	})
+
+	m := NewMigration(db, Options{Driver: driverSQLite, Source: Source{Path: dir}})
+	require.NoError(t, m.Migrate())
+
+	reverted, err := m.RevertTo(0)
+	assert.ErrorIs(t, err, ErrMissingDownScript)
+	assert.Empty(t, reverted)
+
+	// nothing is reverted when a down script in the range is missing
+	assert.True(t, tableExists(t, db, "towns"))
+
+	reverted, err = m.RevertTo(2)
+	require.NoError(t, err)
+	assert.Equal(t, []int{3}, reverted)
+}
+
+func TestMigrateFromDialectDirectory(t *testing.T) {
+	db := newTestDB(t)
+	fs := afero.NewMemMapFs()
+	files := map[string]string{
+		"migrations/0001_create_users.up.sql":           "CREATE TABLE users (id INTEGER PRIMARY KEY);",
+		"migrations/0001_create_users.down.sql":         "DROP TABLE users;",
+		"migrations/0002_add_email.up.sql":              "ALTER TABLE users ADD COLUMN email TEXT;",
+		"migrations/sqlite/0002_add_email.up.sql":       "ALTER TABLE users ADD COLUMN email TEXT NOT NULL DEFAULT '';",
+		"migrations/sqlite/0003_create_towns.up.sql":    "CREATE TABLE towns (id INTEGER PRIMARY KEY);",
+		"migrations/postgres/0003_create_cities.up.sql": "CREATE TABLE cities (id SERIAL PRIMARY KEY);",
+	}
+	for name, content := range files {
+		require.NoError(t, afero.WriteFile(fs, name, []byte(content), 0o644))
+	}
+
+	m := NewMigration(db, Options{Driver: driverMemory, Source: Source{Path: "migrations", Fs: fs}})
+	require.NoError(t, m.Migrate())
+
+	assert.True(t, tableExists(t, db, "towns"))
+	assert.False(t, tableExists(t, db, "cities"))
+
+	var sql string
+	require.NoError(t, db.Get(&sql, "SELECT sql FROM sqlite_master WHERE name = 'users'"))
+	assert.Contains(t, sql, "NOT NULL DEFAULT ''")
+
+	assert.ErrorContains(t, NewMigration(db, Options{Driver: driverSQLite, Source: Source{Path: "missing", Fs: fs}}).Migrate(), "does not exist")
+}
+
+func TestDialectFileResolution(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	files := map[string]string{
+		"m/0001_create_users.up.sql":                  "shared",
+		"m/0001_create_users.postgres.up.sql":         "postgres",
+		"m/0001_create_users.sqlite.up.sql":           "sqlite",
+		"m/0002_add_index.up.sql":                     "shared",
+		"m/0002_add_index.down.sql":                   "shared down",
+		"m/postgres/0002_add_index.up.sql":            "postgres dir",
+		"m/0002_add_index.postgres.up.sql":            "postgres suffix",
+		"m/postgres/0002_add_index.postgres.down.sql": "postgres dir suffix",
+		"m/0003_oracle_only.oracle.up.sql":            "oracle",
+	}
+	for name, content := range files {
+		require.NoError(t, afero.WriteFile(fs, name, []byte(content), 0o644))
+	}
+
+	load := func(driver string) map[int]*script {
+		d, err := dialectFor(driver)
+		require.NoError(t, err)
+
+		scripts, err := loadScripts(fs, "m", d.name)
+		require.NoError(t, err)
+
+		byVersion := make(map[int]*script)
+		for _, s := range scripts {
+			byVersion[s.version] = s
+		}
+		return byVersion
+	}
+
+	postgres := load(driverPostgres)
+	assert.Equal(t, "postgres", postgres[1].up)
+	assert.Equal(t, "postgres dir", postgres[2].up)
+	assert.Equal(t, "postgres dir suffix", postgres[2].down)
+	assert.NotContains(t, postgres, 3)
+
+	memory := load(driverMemory)
+	assert.Equal(t, "sqlite", memory[1].up)
+	assert.Equal(t, "shared", memory[2].up)
+	assert.Equal(t, "shared down", memory[2].down)
+
+	mysql := load(driverMySQL)
+	assert.Equal(t, "shared", mysql[1].up)
+
+	oracle := load(driverOracle)
+	assert.Equal(t, "oracle", oracle[3].up)
+
+	require.NoError(t, afero.WriteFile(fs, "m/0004_twice.up.sql", []byte("a"), 0o644))
+	require.NoError(t, afero.WriteFile(fs, "m/0004_twice_again.up.sql", []byte("b"), 0o644))
+	_, err := loadScripts(fs, "m", driverPostgres)
+	assert.ErrorContains(t, err, "conflicting names")
+}
+
+func TestGoMigrations(t *testing.T) {
+	db := newTestDB(t)
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"0001_create_users.up.sql":   "CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT);",
+		"0001_create_users.down.sql": "DROP TABLE users;",
+		"0003_add_email.up.sql":      "ALTER TABLE users ADD COLUMN email TEXT;",
+		"0003_add_email.down.sql":    "ALTER TABLE users DROP COLUMN email;",
+	})
+
+	var order []string
+	m := NewMigration(db, Options{Driver: driverSQLite, Source: Source{Path: dir}})
+	require.NoError(t, m.Register(2, "seed_users",
+		func(ctx context.Context, tx *sqlx.Tx) error {
+			order = append(order, "up 2")
+			_, err := tx.ExecContext(ctx, "INSERT INTO users (name) VALUES ('alice'), ('bob')")
+			return err
+		},
+		func(ctx context.Context, tx *sqlx.Tx) error {
+			order = append(order, "down 2")
+			_, err := tx.ExecContext(ctx, "DELETE FROM users")
+			return err
+		},
+	))
+	// engines derived with WithContext share the Go migrations
+	require.NoError(t, m.WithContext(context.Background()).Register(4, "backfill_email", func(ctx context.Context, tx *sqlx.Tx) error {
+		order = append(order, "up 4")
+		_, err := tx.ExecContext(ctx, "UPDATE users SET email = name || '@example.com'")
+		return err
+	}, nil))
+
+	assert.ErrorContains(t, m.Register(2, "again", func(context.Context, *sqlx.Tx) error { return nil }, nil), "already registered")
+	assert.ErrorContains(t, m.Register(5, "no_up", nil, nil), "up function is nil")
+
+	require.NoError(t, m.Migrate())
+	assert.Equal(t, []string{"up 2", "up 4"}, order)
+
+	var emails []string
+	require.NoError(t, db.Select(&emails, "SELECT email FROM users ORDER BY name"))
+	assert.Equal(t, []string{"alice@example.com", "bob@example.com"}, emails)
+
+	var versions []int
+	require.NoError(t, db.Select(&versions, "SELECT version FROM schema_migrations ORDER BY version"))
+	assert.Equal(t, []int{1, 2, 3, 4}, versions)
+
+	// version 4 has no down function
+	_, err := m.RevertTo(1)
+	assert.ErrorIs(t, err, ErrMissingDownScript)
+
+	_, err = db.Exec("DELETE FROM schema_migrations WHERE version = 4")
+	require.NoError(t, err)
+
+	reverted, err := m.RevertTo(1)
+	require.NoError(t, err)
+	assert.Equal(t, []int{3, 2}, reverted)
+	assert.Equal(t, "down 2", order[len(order)-1])
+
+	conflict := NewMigration(db, Options{Driver: driverSQLite, Source: Source{Path: dir, GoMigrations: []GoMigration{
+		{Version: 3, Name: "clash", Up: func(context.Context, *sqlx.Tx) error { return nil }},
+	}}})
+	assert.ErrorContains(t, conflict.Migrate(), "conflicts with migration file 3")
+}
+
+func TestValidateDrift(t *testing.T) {
+	db := newTestDB(t)
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"0001_create_users.up.sql":    "CREATE TABLE users (\n\tid INTEGER PRIMARY KEY\n);\n",
+		"0001_create_users.down.sql":  "DROP TABLE users;",
+		"0002_create_cities.up.sql":   "CREATE TABLE cities (id INTEGER PRIMARY KEY);",
+		"0002_create_cities.down.sql": "DROP TABLE cities;",
+	})
+
+	m := NewMigration(db, Options{Driver: driverSQLite, Source: Source{Path: dir}})
+	require.NoError(t, m.Validate(""))
+	assert.False(t, tableExists(t, db, DefaultTableName), "validating does not create the bookkeeping table")
+	require.NoError(t, m.Migrate())
+	require.NoError(t, m.Validate(dir))
+
+	var recorded string
+	require.NoError(t, db.Get(&recorded, "SELECT checksum FROM schema_migrations WHERE version = 2"))
+
+	// line ending changes are not drift
+	writeFiles(t, dir, map[string]string{
+		"0001_create_users.up.sql": "CREATE TABLE users (\r\n\tid INTEGER PRIMARY KEY\r\n);\r\n",
+	})
+	require.NoError(t, m.Validate(""))
+
+	writeFiles(t, dir, map[string]string{
+		"0002_create_cities.up.sql": "CREATE TABLE cities (id INTEGER PRIMARY KEY, name TEXT);",
+	})
+
+	err := m.Validate("")
+	require.ErrorIs(t, err, ErrChecksumMismatch)
+
+	var drift *DriftError
+	require.ErrorAs(t, err, &drift)
+	assert.Equal(t, 2, drift.Version)
+	assert.Equal(t, "create_cities", drift.Name)
+	assert.Equal(t, recorded, drift.Recorded)
+	assert.NotEqual(t, drift.Recorded, drift.Current)
+
+	require.NoError(t, os.Remove(filepath.Join(dir, "0002_create_cities.up.sql")))
+	require.NoError(t, os.Remove(filepath.Join(dir, "0002_create_cities.down.sql")))
+	assert.ErrorIs(t, m.Validate(""), ErrUnknownMigration)
+}
+
+func TestAppliedMigrationsColumns(t *testing.T) {
+	db := newTestDB(t)
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"0001_create_users.up.sql":   "CREATE TABLE users (id INTEGER PRIMARY KEY);",
+		"0001_create_users.down.sql": "DROP TABLE users;",
+	})
+
+	// uppercase columns as Oracle reports them and applied_at as text as MySQL returns it without parseTime
+	m := NewMigration(db, Options{Driver: driverSQLite, Source: Source{Path: dir}})
+	scripts, err := m.(*migrationProvider).load(dir)
+	require.NoError(t, err)
+	checksum := scripts[0].checksum()
+	_, err = db.Exec(`CREATE TABLE schema_migrations (VERSION INTEGER PRIMARY KEY, NAME TEXT, CHECKSUM TEXT, APPLIED_AT TEXT)`)
+	require.NoError(t, err)
+	_, err = db.Exec("INSERT INTO schema_migrations VALUES (1, 'create_users', ?, '2024-05-06 07:08:09')", checksum)
+	require.NoError(t, err)
+
+	require.NoError(t, m.Validate(""))
+
+	statuses, err := m.Status()
+	require.NoError(t, err)
+	require.Len(t, statuses, 1)
+	require.NotNil(t, statuses[0].AppliedAt)
+	assert.Equal(t, time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC), *statuses[0].AppliedAt)
+}
+
+func TestTimestampScan(t *testing.T) {
+	want := time.Date(2024, 5, 6, 7, 8, 9, 500, time.UTC)
+	for _, value := range []any{want, []byte("2024-05-06 07:08:09.0000005"), "2024-05-06T07:08:09.0000005Z"} {
+		var ts timestamp
+		require.NoError(t, ts.Scan(value))
+		assert.True(t, want.Equal(time.Time(ts)), "%v", value)
+	}
+
+	var ts timestamp
+	assert.Error(t, ts.Scan(42))
+	assert.Error(t, ts.Scan("yesterday"))
+}
+
+func TestValidateFiles(t *testing.T) {
+	db := newTestDB(t)
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"0001_create_users.up.sql":   "CREATE TABLE users (id INTEGER PRIMARY KEY);",
+		"0001_create_users.down.sql": "DROP TABLE users;",
+		"0002_seed_users.up.sql":     "INSERT INTO users (id) VALUES (1);",
+		"0004_create_towns.up.sql":   "CREATE TABLE towns (id INTEGER PRIMARY KEY);",
+		"0004_create_towns.down.sql": "DROP TABLE towns;",
+	})
+
+	m := NewMigration(db, Options{Driver: driverSQLite, Source: Source{Path: dir}})
+	err := m.Validate("")
+	assert.ErrorIs(t, err, ErrMissingDownScript)
+	assert.ErrorIs(t, err, ErrVersionGap)
+	assert.ErrorContains(t, err, "4 follows 2")
+
+	// an irreversible Go migration fills the gap
+	require.NoError(t, m.Register(3, "backfill", func(context.Context, *sqlx.Tx) error { return nil }, nil))
+	writeFiles(t, dir, map[string]string{"0002_seed_users.down.sql": "DELETE FROM users;"})
+	assert.NoError(t, m.Validate(""))
+}
+
+func TestMigrateWaitsForLock(t *testing.T) {
+	db := newTestDB(t)
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"0001_create_users.up.sql":   "CREATE TABLE users (id INTEGER PRIMARY KEY);",
+		"0001_create_users.down.sql": "DROP TABLE users;",
+	})
+
+	holder := NewMigration(db, Options{Driver: driverSQLite, Source: Source{Path: dir}}).(*migrationProvider)
+	l := holder.dialect.newLocker(holder)
+	ok, _, err := l.tryLock(context.Background())
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+
+	err = NewMigration(db, Options{Driver: driverSQLite, Source: Source{Path: dir}, Context: ctx}).Migrate()
+	require.ErrorIs(t, err, ErrLocked)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	var lockErr *LockError
+	require.ErrorAs(t, err, &lockErr)
+	hostname, _ := os.Hostname()
+	assert.Contains(t, lockErr.Holder, fmt.Sprintf("%s:%d:", hostname, os.Getpid()))
+	assert.False(t, tableExists(t, db, "users"))
+
+	require.NoError(t, l.release(context.Background()))
+	require.NoError(t, NewMigration(db, Options{Driver: driverSQLite, Source: Source{Path: dir}}).Migrate())
+	assert.True(t, tableExists(t, db, "users"))
+}
+
+func TestStaleLock(t *testing.T) {
+	db := newTestDB(t)
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"0001_create_users.up.sql": "CREATE TABLE users (id INTEGER PRIMARY KEY);"})
+
+	// a holder that crashed an hour ago
+	crashed := NewMigration(db, Options{Driver: driverSQLite, Source: Source{Path: dir}}).(*migrationProvider)
+	ok, _, err := crashed.dialect.newLocker(crashed).tryLock(context.Background())
+	require.NoError(t, err)
+	require.True(t, ok)
+	_, err = db.Exec("UPDATE schema_migrations_lock SET acquired_at = ?", time.Now().Add(-time.Hour).UTC())
+	require.NoError(t, err)
+
+	err = NewMigration(db, Options{Driver: driverSQLite, Source: Source{Path: dir}}).Migrate()
+	require.ErrorIs(t, err, ErrStaleLock)
+	assert.ErrorContains(t, err, "delete the row of schema_migrations_lock")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	err = NewMigration(db, Options{Driver: driverSQLite, Source: Source{Path: dir}, Context: ctx, StaleLockAfter: -1}).Migrate()
+	require.ErrorIs(t, err, ErrLocked)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	_, err = db.Exec("DELETE FROM schema_migrations_lock")
+	require.NoError(t, err)
+	require.NoError(t, NewMigration(db, Options{Driver: driverSQLite, Source: Source{Path: dir}}).Migrate())
+	assert.True(t, tableExists(t, db, "users"))
+}
+
+func TestUnknownHolder(t *testing.T) {
+	assert.Contains(t, unknownHolder(sql.ErrNoRows), "released")
+	assert.Equal(t, "unknown (reading the holder failed: permission denied for view pg_stat_activity)",
+		unknownHolder(errors.New("permission denied for view pg_stat_activity")))
+}
+
+func TestConcurrentMigrate(t *testing.T) {
+	dsn := "file:" + filepath.Join(t.TempDir(), "concurrent.sqlite3") + "?_pragma=busy_timeout(5000)"
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"0001_create_users.up.sql":   "CREATE TABLE users (id INTEGER PRIMARY KEY);",
+		"0002_seed_users.up.sql":     "INSERT INTO users (id) VALUES (1);",
+		"0003_create_cities.up.sql":  "CREATE TABLE cities (id INTEGER PRIMARY KEY);",
+		"0001_create_users.down.sql": "DROP TABLE users;",
+	})
+
+	const replicas = 4
+	errs := make(chan error, replicas)
+	for i := 0; i < replicas; i++ {
+		db, err := sqlx.Connect("sqlite", dsn)
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = db.Close() })
+
+		go func() {
+			errs <- NewMigration(db, Options{Driver: driverSQLite, Source: Source{Path: dir}}).Migrate()
+		}()
+	}
+
+	for i := 0; i < replicas; i++ {
+		require.NoError(t, <-errs)
+	}
+
+	db, err := sqlx.Connect("sqlite", dsn)
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	var count int
+	require.NoError(t, db.Get(&count, "SELECT COUNT(*) FROM users"))
+	assert.Equal(t, 1, count)
+}
+
+func TestPlanAndStatus(t *testing.T) {
+	db := newTestDB(t)
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"0001_create_users.up.sql":            "CREATE TABLE users (id INTEGER PRIMARY KEY);",
+		"0001_create_users.down.sql":          "DROP TABLE users;",
+		"0002_create_cities.up.sql":           "CREATE TABLE cities (id INTEGER PRIMARY KEY);\nCREATE INDEX idx_cities ON cities (id);",
+		"0002_create_cities.mysql.up.sql":     "CREATE TABLE cities (id INTEGER PRIMARY KEY AUTO_INCREMENT);\nCREATE INDEX idx_cities ON cities (id);",
+		"0002_create_cities.down.sql":         "DROP TABLE cities;",
+		"0004_create_towns.up.sql":            "CREATE TABLE towns (id INTEGER PRIMARY KEY);",
+		"0004_create_towns.down.sql":          "DROP TABLE towns;",
+		"0004_create_towns.postgres.down.sql": "DROP TABLE towns CASCADE;",
+	})
+
+	backfill := func(context.Context, *sqlx.Tx) error { return nil }
+	m := NewMigration(db, Options{Driver: driverSQLite, Source: Source{Path: dir, GoMigrations: []GoMigration{
+		{Version: 3, Name: "backfill", Up: backfill},
+	}}})
+
+	// planning does not create the bookkeeping table
+	plan, err := m.Plan()
+	require.NoError(t, err)
+	require.Len(t, plan, 4)
+	assert.False(t, tableExists(t, db, DefaultTableName))
+
+	assert.Equal(t, []int{1, 2, 3, 4}, []int{plan[0].Version, plan[1].Version, plan[2].Version, plan[3].Version})
+	assert.Equal(t, []string{"CREATE TABLE cities (id INTEGER PRIMARY KEY);\nCREATE INDEX idx_cities ON cities (id);"}, plan[1].Statements)
+	assert.True(t, plan[2].Go)
+	assert.Equal(t, "go", plan[2].Source)
+	assert.Contains(t, plan[3].String(), "-- 4 create_towns (")
+
+	// MySQL scripts are executed one statement at a time
+	scripts, err := loadScripts(afero.NewOsFs(), dir, driverMySQL)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"CREATE TABLE cities (id INTEGER PRIMARY KEY AUTO_INCREMENT)",
+		"CREATE INDEX idx_cities ON cities (id)",
+	}, dialects[driverMySQL].statements(scripts[1].up))
+
+	require.NoError(t, m.Migrate())
+
+	plan, err = m.Plan()
+	require.NoError(t, err)
+	assert.Empty(t, plan)
+
+	_, err = db.Exec("INSERT INTO schema_migrations (version, name, checksum, applied_at) VALUES (9, 'gone', 'x', ?)", time.Now())
+	require.NoError(t, err)
+	writeFiles(t, dir, map[string]string{"0001_create_users.up.sql": "CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT);"})
+	writeFiles(t, dir, map[string]string{"0005_create_roads.up.sql": "CREATE TABLE roads (id INTEGER PRIMARY KEY);"})
+
+	status, err := m.Status()
+	require.NoError(t, err)
+	require.Len(t, status, 6)
+
+	states := make(map[int]State)
+	for _, st := range status {
+		states[st.Version] = st.State
+	}
+	assert.Equal(t, map[int]State{1: StateApplied, 2: StateApplied, 3: StateApplied, 4: StateApplied, 5: StatePending, 9: StateMissing}, states)
+	assert.True(t, status[0].Drifted)
+	assert.False(t, status[1].Drifted)
+	assert.True(t, status[2].Go)
+	assert.NotNil(t, status[0].AppliedAt)
+	assert.Nil(t, status[4].AppliedAt)
+}
@@ -0,0 +1,164 @@
+package migration
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// State is the state of a migration in a status report
+type State string
+
+const (
+	// StateApplied is a migration recorded in the bookkeeping table
+	StateApplied State = "applied"
+
+	// StatePending is a migration that Migrate would apply
+	StatePending State = "pending"
+
+	// StateMissing is a recorded migration whose file or Go migration is gone
+	StateMissing State = "missing"
+)
+
+// PlannedMigration is a pending migration with the statements Migrate would execute
+type PlannedMigration struct {
+	Version    int      `json:"version"`
+	Name       string   `json:"name"`
+	Dialect    string   `json:"dialect"`
+	Source     string   `json:"source"`
+	Go         bool     `json:"go"`
+	Statements []string `json:"statements,omitempty"`
+}
+
+// String renders the planned migration as a SQL script
+func (p PlannedMigration) String() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("-- %d %s (%s)\n", p.Version, p.Name, p.Source))
+
+	if p.Go {
+		sb.WriteString("-- Go migration, statements are only known at run time\n")
+	}
+
+	for _, stmt := range p.Statements {
+		sb.WriteString(strings.TrimRight(stmt, "; \n\t"))
+		sb.WriteString(";\n")
+	}
+
+	return sb.String()
+}
+
+// MigrationStatus is the state of a single migration version
+type MigrationStatus struct {
+	Version   int        `json:"version"`
+	Name      string     `json:"name"`
+	State     State      `json:"state"`
+	Go        bool       `json:"go"`
+	Drifted   bool       `json:"drifted"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+}
+
+// Plan returns the pending migrations in the order Migrate would apply them without executing anything
+func (m *migrationProvider) Plan() ([]PlannedMigration, error) {
+	scripts, records, err := m.inspect()
+	if err != nil {
+		return nil, err
+	}
+
+	if err = checkScripts(scripts); err != nil {
+		return nil, err
+	}
+
+	applied := make(map[int]struct{}, len(records))
+	for _, record := range records {
+		applied[record.Version] = struct{}{}
+	}
+
+	var plan []PlannedMigration
+	for _, s := range scripts {
+		if _, ok := applied[s.version]; ok {
+			continue
+		}
+
+		p := PlannedMigration{
+			Version: s.version,
+			Name:    s.name,
+			Dialect: m.dialect.name,
+			Source:  s.upFile,
+			Go:      s.upFunc != nil,
+		}
+
+		if p.Go {
+			p.Source = "go"
+		} else {
+			p.Statements = m.dialect.statements(s.up)
+		}
+
+		plan = append(plan, p)
+	}
+
+	return plan, nil
+}
+
+// Status reports every known version as applied, pending or missing
+func (m *migrationProvider) Status() ([]MigrationStatus, error) {
+	scripts, records, err := m.inspect()
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]AppliedMigration, len(records))
+	for _, record := range records {
+		byVersion[record.Version] = record
+	}
+
+	status := make([]MigrationStatus, 0, len(scripts))
+	for _, s := range scripts {
+		st := MigrationStatus{Version: s.version, Name: s.name, State: StatePending, Go: s.upFunc != nil}
+
+		if record, ok := byVersion[s.version]; ok {
+			appliedAt := record.AppliedAt
+			st.State = StateApplied
+			st.AppliedAt = &appliedAt
+			st.Drifted = record.Checksum != s.checksum()
+			delete(byVersion, s.version)
+		}
+
+		status = append(status, st)
+	}
+
+	for _, record := range records {
+		if _, ok := byVersion[record.Version]; !ok {
+			continue
+		}
+
+		appliedAt := record.AppliedAt
+		status = append(status, MigrationStatus{
+			Version:   record.Version,
+			Name:      record.Name,
+			State:     StateMissing,
+			AppliedAt: &appliedAt,
+		})
+	}
+
+	sort.Slice(status, func(i, j int) bool {
+		return status[i].Version < status[j].Version
+	})
+
+	return status, nil
+}
+
+// inspect loads the scripts and the applied records without creating the bookkeeping table
+func (m *migrationProvider) inspect() ([]*script, []AppliedMigration, error) {
+	scripts, err := m.load(m.options.Path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	records, err := m.recordedMigrations()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return scripts, records, nil
+}
@@ -0,0 +1,203 @@
+package migration
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+const (
+	directionUp   = "up"
+	directionDown = "down"
+)
+
+// fileNamePattern matches migration files such as 0001_create_users.up.sql and the dialect
+// specific variant 0001_create_users.postgres.up.sql
+var fileNamePattern = regexp.MustCompile(`^(\d+)_([^.]+)(?:\.([a-z0-9]+))?\.(up|down)\.sql$`)
+
+// file precedence, the dialect subdirectory wins over the shared directory and a dialect
+// suffix in the file name adds one to the rank of its directory
+const (
+	rankShared     = 0
+	rankDialectDir = 2
+)
+
+// script holds the up and down SQL of a single migration version
+type script struct {
+	version  int
+	name     string
+	up       string
+	down     string
+	upFile   string
+	downFile string
+	upRank   int
+	downRank int
+
+	// upFunc and downFunc are set for migrations written in Go
+	upFunc     GoMigrationFunc
+	downFunc   GoMigrationFunc
+	goChecksum string
+}
+
+// checksum returns the hex encoded SHA-256 of the up script
+func (s *script) checksum() string {
+	if s.upFunc != nil {
+		return s.goChecksum
+	}
+
+	// line endings are normalized so a checkout with CRLF does not report drift
+	sum := sha256.Sum256([]byte(strings.ReplaceAll(s.up, "\r\n", "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// hasUp reports if the migration can be applied
+func (s *script) hasUp() bool {
+	return s.upFile != "" || s.upFunc != nil
+}
+
+// hasDown reports if the migration can be reverted
+func (s *script) hasDown() bool {
+	return s.downFile != "" || s.downFunc != nil
+}
+
+// migrationFile describes a migration file name
+type migrationFile struct {
+	version   int
+	name      string
+	dialect   string
+	direction string
+}
+
+// parseFileName extracts version, name, dialect and direction from a migration file name
+func parseFileName(fileName string) (migrationFile, bool) {
+	match := fileNamePattern.FindStringSubmatch(fileName)
+	if match == nil {
+		return migrationFile{}, false
+	}
+
+	version, err := strconv.Atoi(match[1])
+	if err != nil {
+		return migrationFile{}, false
+	}
+
+	return migrationFile{
+		version:   version,
+		name:      match[2],
+		dialect:   match[3],
+		direction: match[4],
+	}, true
+}
+
+// loadScripts reads the migration files of dir and of its dialect subdirectory and returns them sorted by
+// version. Files in the dialect subdirectory take precedence over the shared ones in dir and, inside each
+// directory, files with the dialect suffix take precedence over the ones without it
+func loadScripts(fs afero.Fs, dir, dialectName string) ([]*script, error) {
+	ok, err := afero.DirExists(fs, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		return nil, fmt.Errorf("directory %s does not exist", dir)
+	}
+
+	byVersion := make(map[int]*script)
+	if err = readScripts(fs, dir, dialectName, rankShared, byVersion); err != nil {
+		return nil, err
+	}
+
+	dialectDir := path.Join(dir, dialectName)
+	if ok, err = afero.DirExists(fs, dialectDir); err != nil {
+		return nil, err
+	}
+
+	if ok {
+		if err = readScripts(fs, dialectDir, dialectName, rankDialectDir, byVersion); err != nil {
+			return nil, err
+		}
+	}
+
+	scripts := make([]*script, 0, len(byVersion))
+	for _, s := range byVersion {
+		scripts = append(scripts, s)
+	}
+
+	sort.Slice(scripts, func(i, j int) bool {
+		return scripts[i].version < scripts[j].version
+	})
+
+	return scripts, nil
+}
+
+// readScripts adds the migration files of dir to byVersion, a file replaces another one of the same
+// version and direction when its rank is higher
+func readScripts(fs afero.Fs, dir, dialectName string, rank int, byVersion map[int]*script) error {
+	entries, err := afero.ReadDir(fs, dir)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		file, ok := parseFileName(entry.Name())
+		if !ok {
+			continue
+		}
+
+		fileRank := rank
+		if file.dialect != "" {
+			if file.dialect != dialectName {
+				continue
+			}
+			fileRank++
+		}
+
+		s, found := byVersion[file.version]
+		if !found {
+			s = &script{version: file.version, name: file.name, upRank: -1, downRank: -1}
+			byVersion[file.version] = s
+		}
+
+		if s.name != file.name {
+			return fmt.Errorf("migration %d has conflicting names %q and %q", file.version, s.name, file.name)
+		}
+
+		currentRank := s.upRank
+		if file.direction == directionDown {
+			currentRank = s.downRank
+		}
+
+		if fileRank == currentRank {
+			return fmt.Errorf("migration %d has more than one %s file", file.version, file.direction)
+		}
+
+		if fileRank < currentRank {
+			continue
+		}
+
+		fileName := path.Join(dir, entry.Name())
+		content, err := afero.ReadFile(fs, fileName)
+		if err != nil {
+			return err
+		}
+
+		switch file.direction {
+		case directionUp:
+			s.up, s.upFile, s.upRank = string(content), fileName, fileRank
+		case directionDown:
+			s.down, s.downFile, s.downRank = string(content), fileName, fileRank
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,147 @@
+package migration
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrVersionGap is returned when the migration versions are not contiguous
+var ErrVersionGap = errors.New("migration versions are not contiguous")
+
+// ErrChecksumMismatch is returned when an applied migration changed after it was applied
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// ErrUnknownMigration is returned when an applied migration has no file or Go migration anymore
+var ErrUnknownMigration = errors.New("applied migration is unknown")
+
+// AppliedMigration is a migration recorded in the bookkeeping table
+type AppliedMigration struct {
+	Version   int       `db:"version"`
+	Name      string    `db:"name"`
+	Checksum  string    `db:"checksum"`
+	AppliedAt time.Time `db:"applied_at"`
+}
+
+// timestamp scans the applied_at column whether the driver returns a time or its text
+type timestamp time.Time
+
+// timestampLayouts are the text forms of applied_at, MySQL without parseTime and SQLite
+var timestampLayouts = []string{
+	"2006-01-02 15:04:05.999999999-07:00",
+	"2006-01-02 15:04:05.999999999",
+	time.RFC3339Nano,
+}
+
+func (t *timestamp) Scan(value any) error {
+	var text string
+	switch v := value.(type) {
+	case time.Time:
+		*t = timestamp(v)
+		return nil
+	case []byte:
+		text = string(v)
+	case string:
+		text = v
+	default:
+		return fmt.Errorf("applied_at: cannot scan %T into a time", value)
+	}
+
+	for _, layout := range timestampLayouts {
+		if parsed, err := time.Parse(layout, text); err == nil {
+			*t = timestamp(parsed)
+			return nil
+		}
+	}
+
+	return fmt.Errorf("applied_at: cannot parse %q", text)
+}
+
+// DriftError reports an applied migration whose source changed after it was applied
+type DriftError struct {
+	Version  int
+	Name     string
+	Recorded string
+	Current  string
+}
+
+func (e *DriftError) Error() string {
+	return fmt.Sprintf("migration %d (%s) drifted: recorded checksum %s, current checksum %s",
+		e.Version, e.Name, e.Recorded, e.Current)
+}
+
+func (e *DriftError) Unwrap() error {
+	return ErrChecksumMismatch
+}
+
+// Validate checks that the migrations in path are contiguous, that every file migration has an up and a
+// down script and that the applied migrations match the checksums recorded in the database
+func (m *migrationProvider) Validate(path string) error {
+	if path == "" {
+		path = m.options.Path
+	}
+
+	scripts, err := m.load(path)
+	if err != nil {
+		return err
+	}
+
+	errs := validateScripts(scripts)
+
+	// read only, the check runs in CI with credentials that cannot create the bookkeeping table
+	records, err := m.recordedMigrations()
+	if err != nil {
+		return errors.Join(append(errs, err)...)
+	}
+
+	errs = append(errs, checkDrift(scripts, records)...)
+
+	return errors.Join(errs...)
+}
+
+// validateScripts checks the sequence of versions and the up/down pairs
+func validateScripts(scripts []*script) []error {
+	var errs []error
+	for i, s := range scripts {
+		if !s.hasUp() {
+			errs = append(errs, fmt.Errorf("migration %d (%s) has no up file", s.version, s.name))
+		}
+
+		// Go migrations without a down function are irreversible on purpose
+		if s.upFunc == nil && s.downFile == "" {
+			errs = append(errs, fmt.Errorf("migration %d (%s): %w", s.version, s.name, ErrMissingDownScript))
+		}
+
+		if i > 0 && s.version != scripts[i-1].version+1 {
+			errs = append(errs, fmt.Errorf("%w: %d follows %d", ErrVersionGap, s.version, scripts[i-1].version))
+		}
+	}
+	return errs
+}
+
+// checkDrift compares the recorded checksums with the current ones
+func checkDrift(scripts []*script, records []AppliedMigration) []error {
+	byVersion := make(map[int]*script, len(scripts))
+	for _, s := range scripts {
+		byVersion[s.version] = s
+	}
+
+	var errs []error
+	for _, record := range records {
+		s, ok := byVersion[record.Version]
+		if !ok {
+			errs = append(errs, fmt.Errorf("migration %d (%s): %w", record.Version, record.Name, ErrUnknownMigration))
+			continue
+		}
+
+		if current := s.checksum(); current != record.Checksum {
+			errs = append(errs, &DriftError{
+				Version:  record.Version,
+				Name:     record.Name,
+				Recorded: record.Checksum,
+				Current:  current,
+			})
+		}
+	}
+	return errs
+}
@@ -0,0 +1,470 @@
+package provider
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	// DefaultReconnectAttempts is the number of attempts of ReconnectDatabase when MaxAttempts is not set
+	DefaultReconnectAttempts = 5
+
+	// DefaultReconnectInitialBackoff is the wait after the first failed attempt
+	DefaultReconnectInitialBackoff = 100 * time.Millisecond
+
+	// DefaultReconnectMaxBackoff caps the wait between two attempts
+	DefaultReconnectMaxBackoff = 10 * time.Second
+
+	// DefaultReconnectMultiplier grows the wait after every failed attempt
+	DefaultReconnectMultiplier = 2.0
+
+	// DefaultReconnectJitter is the fraction of the wait that is randomized
+	DefaultReconnectJitter = 0.2
+)
+
+var (
+	// ErrReconnectFailed is returned when ReconnectDatabase gave up, it wraps the last connection error
+	ErrReconnectFailed = errors.New("reconnect failed")
+
+	// ErrProviderClosed is returned by the methods of a provider once Disconnect was called
+	ErrProviderClosed = errors.New("provider is closed")
+)
+
+const (
+	// DefaultShutdownTimeout bounds the wait of Disconnect for the connections in use
+	DefaultShutdownTimeout = 30 * time.Second
+
+	// shutdownPoll is the interval at which a shutdown checks the connections in use
+	shutdownPoll = 10 * time.Millisecond
+)
+
+// ReconnectOptions configures the exponential backoff of ReconnectDatabase, zero values pick the defaults
+// and a negative MaxAttempts retries until the context is done
+type ReconnectOptions struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Multiplier     float64
+
+	// Jitter randomizes each wait by up to this fraction, between 0 and 1
+	Jitter float64
+}
+
+// withDefaults fills the zero values
+func (r ReconnectOptions) withDefaults() ReconnectOptions {
+	if r.MaxAttempts == 0 {
+		r.MaxAttempts = DefaultReconnectAttempts
+	}
+	if r.InitialBackoff <= 0 {
+		r.InitialBackoff = DefaultReconnectInitialBackoff
+	}
+	if r.MaxBackoff <= 0 {
+		r.MaxBackoff = DefaultReconnectMaxBackoff
+	}
+	if r.Multiplier < 1 {
+		r.Multiplier = DefaultReconnectMultiplier
+	}
+	if r.Jitter == 0 {
+		r.Jitter = DefaultReconnectJitter
+	}
+	return r
+}
+
+// validate checks the ranges of the reconnect options
+func (r ReconnectOptions) validate() []error {
+	var errs []error
+
+	if r.InitialBackoff < 0 || r.MaxBackoff < 0 {
+		errs = append(errs, fmt.Errorf("%w: backoff durations cannot be negative", ErrInvalidReconnect))
+	}
+
+	if r.Multiplier != 0 && r.Multiplier < 1 {
+		errs = append(errs, fmt.Errorf("%w: multiplier %v is below 1", ErrInvalidReconnect, r.Multiplier))
+	}
+
+	if r.Jitter < 0 || r.Jitter > 1 {
+		errs = append(errs, fmt.Errorf("%w: jitter %v is not within 0-1", ErrInvalidReconnect, r.Jitter))
+	}
+
+	return errs
+}
+
+// backoff returns the wait before the next attempt, randomized by the jitter
+func (r ReconnectOptions) backoff(wait time.Duration) time.Duration {
+	if r.Jitter <= 0 {
+		return wait
+	}
+
+	delta := float64(wait) * r.Jitter
+	return time.Duration(float64(wait) - delta + rand.Float64()*2*delta)
+}
+
+// shutdownTimeout returns the time Disconnect waits for the connections in use
+func (o *Options) shutdownTimeout() time.Duration {
+	if o.ShutdownTimeout > 0 {
+		return o.ShutdownTimeout
+	}
+	return DefaultShutdownTimeout
+}
+
+// openFunc builds the connector of a pool from the current options
+type openFunc func() (driver.Connector, error)
+
+// connectorFor returns an openFunc building a connector of the database/sql driver for the DSN of the
+// options, the DSN is read on every call so a reconnect picks up changed options
+func connectorFor(sqlDriver string, options *Options) openFunc {
+	return func() (driver.Connector, error) {
+		dsn := options.DSN()
+
+		db, err := sql.Open(sqlDriver, dsn)
+		if err != nil {
+			return nil, err
+		}
+		drv := db.Driver()
+		_ = db.Close()
+
+		if dc, ok := drv.(driver.DriverContext); ok {
+			return dc.OpenConnector(dsn)
+		}
+		return dsnConnector{dsn: dsn, driver: drv}, nil
+	}
+}
+
+// dsnConnector is the connector of a driver without driver.DriverContext
+type dsnConnector struct {
+	dsn    string
+	driver driver.Driver
+}
+
+func (c dsnConnector) Connect(context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.dsn)
+}
+
+func (c dsnConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// switchConnector hands the pool the connections of its current connector, reconnecting replaces the
+// connector so the pool and the *sqlx.DB given to callers stay the same
+type switchConnector struct {
+	current atomic.Pointer[driver.Connector]
+}
+
+func (c *switchConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	return (*c.current.Load()).Connect(ctx)
+}
+
+func (c *switchConnector) Driver() driver.Driver {
+	return (*c.current.Load()).Driver()
+}
+
+// swap installs connector and returns the previous one
+func (c *switchConnector) swap(connector driver.Connector) driver.Connector {
+	if old := c.current.Swap(&connector); old != nil {
+		return *old
+	}
+	return nil
+}
+
+// Close closes the current connector when it holds resources, database/sql calls it when the pool closes
+func (c *switchConnector) Close() error {
+	return closeConnector(*c.current.Load())
+}
+
+// closeConnector closes the connectors holding resources, such as the session pool of godror
+func closeConnector(connector driver.Connector) error {
+	if closer, ok := connector.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// connection holds the connection pool of a provider. The pool is opened once on a switchConnector and
+// reconnecting moves it to a new connector, so a *sqlx.DB returned by GetConnection is never closed by
+// a reconnect
+type connection struct {
+	handle    *sqlx.DB
+	connector *switchConnector
+	open      openFunc
+	driver    string
+	pool      PoolOptions
+
+	// healthTimeout bounds the queries run for the status
+	healthTimeout time.Duration
+
+	// reconnecting serializes the reconnects
+	reconnecting sync.Mutex
+
+	// mu guards the fields below, closed is set once and no connector is swapped in after it
+	mu            sync.Mutex
+	closed        bool
+	openedAt      time.Time
+	lastPing      time.Time
+	pingLatency   time.Duration
+	serverVersion string
+}
+
+// newConnection opens the pool of a provider with the database/sql driver, applies the pool options and
+// pings the database
+func newConnection(ctx context.Context, sqlDriver string, options *Options) (*connection, error) {
+	return openConnection(ctx, options.Driver, sqlDriver, options, connectorFor(sqlDriver, options))
+}
+
+// openConnection opens the pool on the connectors built by open
+func openConnection(ctx context.Context, driver, sqlDriver string, options *Options, open openFunc) (*connection, error) {
+	connector, err := open()
+	if err != nil {
+		return nil, err
+	}
+
+	c := &connection{connector: &switchConnector{}, open: open, driver: driver, openedAt: time.Now()}
+	c.connector.swap(connector)
+	c.handle = sqlx.NewDb(sql.OpenDB(c.connector), sqlDriver)
+
+	var wal bool
+	if driver == SQLiteDataProviderName {
+		if wal, err = sqliteWAL(ctx, c.handle); err != nil {
+			_ = c.handle.Close()
+			return nil, err
+		}
+	}
+
+	c.pool = options.pool(wal)
+	c.healthTimeout = options.healthTimeout()
+	applyPool(c.handle, c.pool)
+
+	if err = c.handle.PingContext(ctx); err != nil {
+		_ = c.handle.Close()
+		return nil, err
+	}
+
+	c.readVersion(ctx)
+	return c, nil
+}
+
+// db returns the pool
+func (c *connection) db() *sqlx.DB {
+	return c.handle
+}
+
+// err returns ErrProviderClosed once the connection is closed
+func (c *connection) err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return ErrProviderClosed
+	}
+	return nil
+}
+
+// shutdown stops accepting work, waits until no connection of the pool is in use or ctx is done, then
+// closes the pool. The pool is closed in both cases, the error tells how many connections were still
+// in use. Closing a closed connection does nothing
+func (c *connection) shutdown(ctx context.Context) error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	c.mu.Unlock()
+
+	return errors.Join(c.drain(ctx), c.handle.Close())
+}
+
+// drain waits until no connection of the pool is in use or ctx is done
+func (c *connection) drain(ctx context.Context) error {
+	ticker := time.NewTicker(shutdownPoll)
+	defer ticker.Stop()
+
+	for c.handle.Stats().InUse > 0 {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w with %d connections in use", ctx.Err(), c.handle.Stats().InUse)
+		case <-ticker.C:
+		}
+	}
+
+	return nil
+}
+
+// reconnect moves the pool to a new connector, retrying with exponential backoff. The handle returned by
+// GetConnection stays the same and keeps working, see replace
+func (c *connection) reconnect(ctx context.Context, options ReconnectOptions) error {
+	c.reconnecting.Lock()
+	defer c.reconnecting.Unlock()
+
+	options = options.withDefaults()
+	wait := options.InitialBackoff
+
+	for attempt := 1; ; attempt++ {
+		if err := c.err(); err != nil {
+			return err
+		}
+
+		err := c.replace(ctx)
+		if err == nil || errors.Is(err, ErrProviderClosed) {
+			return err
+		}
+
+		if options.MaxAttempts > 0 && attempt >= options.MaxAttempts {
+			return fmt.Errorf("%w after %d attempts: %w", ErrReconnectFailed, attempt, err)
+		}
+
+		timer := time.NewTimer(options.backoff(wait))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("%w after %d attempts: %w", ErrReconnectFailed, attempt, errors.Join(ctx.Err(), err))
+		case <-timer.C:
+		}
+
+		wait = min(time.Duration(float64(wait)*options.Multiplier), options.MaxBackoff)
+	}
+}
+
+// replace builds a connector, checks that it connects and installs it, the pool opens its next
+// connections with it. The idle connections of the previous connector are closed, the ones in use finish
+// their work and are retired in the background, see retire
+func (c *connection) replace(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	connector, err := c.open()
+	if err != nil {
+		return err
+	}
+
+	probe, err := connector.Connect(ctx)
+	if err != nil {
+		_ = closeConnector(connector)
+		return err
+	}
+
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		_ = probe.Close()
+		_ = closeConnector(connector)
+		return ErrProviderClosed
+	}
+	old := c.connector.swap(connector)
+	c.openedAt, c.lastPing, c.pingLatency, c.serverVersion = time.Now(), time.Time{}, 0, ""
+	c.mu.Unlock()
+
+	c.closeIdle()
+	go c.retire(old, probe)
+	return nil
+}
+
+// retire waits, up to the shutdown timeout, until the connections of the previous connector are no
+// longer in use, closes them and then the connector. The probe connection of the new connector is kept
+// until the pool holds a connection of its own because an in-memory database only lives as long as one
+// of its connections
+func (c *connection) retire(old driver.Connector, probe driver.Conn) {
+	defer func() { _ = probe.Close() }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultShutdownTimeout)
+	defer cancel()
+
+	_ = c.drain(ctx)
+	c.closeIdle()
+	_ = c.handle.PingContext(ctx)
+	_ = closeConnector(old)
+}
+
+// closeIdle closes the idle connections of the pool
+func (c *connection) closeIdle() {
+	c.handle.SetMaxIdleConns(0)
+	c.handle.SetMaxIdleConns(c.pool.MaxIdleConns)
+}
+
+// check pings the database, or runs the health query when there is one, and records the latency when
+// it succeeds
+func (c *connection) check(ctx context.Context, query string) error {
+	if err := c.err(); err != nil {
+		return err
+	}
+
+	dbHandle := c.db()
+	start := time.Now()
+
+	var err error
+	if query == "" {
+		err = dbHandle.PingContext(ctx)
+	} else {
+		var rows *sqlx.Rows
+		if rows, err = dbHandle.QueryxContext(ctx, query); err == nil {
+			err = rows.Close()
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.lastPing, c.pingLatency = time.Now(), time.Since(start)
+	c.mu.Unlock()
+
+	return nil
+}
+
+// readVersion reads the server version once, failures are retried on the next call. The query runs
+// outside the lock and within the health timeout so a stalled server does not block the provider
+func (c *connection) readVersion(ctx context.Context) {
+	c.mu.Lock()
+	known := c.serverVersion != ""
+	c.mu.Unlock()
+
+	if known {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.healthTimeout)
+	defer cancel()
+
+	var version string
+	if err := c.db().GetContext(ctx, &version, versionQueries[c.driver]); err != nil {
+		return
+	}
+
+	c.mu.Lock()
+	c.serverVersion = version
+	c.mu.Unlock()
+}
+
+// status builds the provider status with the pool statistics
+func (c *connection) status(ctx context.Context, availability error) Status {
+	status := Status{
+		Driver:   c.driver,
+		IsActive: availability == nil,
+		Error:    availability,
+		Stats:    c.db().Stats(),
+	}
+
+	if availability == nil {
+		c.readVersion(ctx)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	status.ServerVersion = c.serverVersion
+	status.Uptime = time.Since(c.openedAt)
+	status.LastPing = c.lastPing
+	status.PingLatency = c.pingLatency
+
+	return status
+}
@@ -0,0 +1,110 @@
+package provider
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	_ "github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+)
+
+// closedPort returns a local port nothing listens on, connecting to it is refused
+func closedPort(t *testing.T) int {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := listener.Addr().(*net.TCPAddr).Port
+	require.NoError(t, listener.Close())
+
+	return port
+}
+
+func TestConnectionReconnect(t *testing.T) {
+	refused := fmt.Sprintf("host=127.0.0.1 port=%d user=app dbname=app sslmode=disable connect_timeout=1", closedPort(t))
+
+	var attempts int
+	fail := true
+	open := func() (driver.Connector, error) {
+		attempts++
+
+		name, dsn := "sqlite", "file:reconnect?mode=memory&cache=shared"
+		if fail {
+			name, dsn = "postgres", refused
+		}
+
+		db, err := sql.Open(name, dsn)
+		if err != nil {
+			return nil, err
+		}
+		defer func() { _ = db.Close() }()
+
+		return dsnConnector{dsn: dsn, driver: db.Driver()}, nil
+	}
+
+	fail = false
+	options := &Options{Driver: MemoryDataProviderName}
+	conn, err := openConnection(context.Background(), MemoryDataProviderName, "sqlite", options, open)
+	require.NoError(t, err)
+	defer func() { _ = conn.shutdown(context.Background()) }()
+
+	held := conn.db()
+	_, err = held.Exec("CREATE TABLE kept (id INTEGER)")
+	require.NoError(t, err)
+	fail, attempts = true, 0
+
+	backoff := ReconnectOptions{MaxAttempts: 4, InitialBackoff: 20 * time.Millisecond, MaxBackoff: 30 * time.Millisecond, Jitter: 0.01}
+	start := time.Now()
+	err = conn.reconnect(context.Background(), backoff)
+	assert.ErrorIs(t, err, ErrReconnectFailed)
+	assert.ErrorContains(t, err, "connection refused")
+	assert.Equal(t, 4, attempts)
+	assert.GreaterOrEqual(t, time.Since(start), 70*time.Millisecond, "waits of 20ms, 30ms and 30ms")
+	assert.NoError(t, held.Ping(), "the pool is kept when reconnect fails")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	attempts = 0
+	err = conn.reconnect(ctx, ReconnectOptions{MaxAttempts: -1, InitialBackoff: 10 * time.Millisecond})
+	assert.ErrorIs(t, err, ErrReconnectFailed)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Greater(t, attempts, 1)
+
+	tx, err := held.Beginx()
+	require.NoError(t, err)
+
+	fail = false
+	require.NoError(t, conn.reconnect(context.Background(), backoff))
+	assert.Same(t, held, conn.db(), "GetConnection keeps returning the same handle")
+
+	_, err = tx.Exec("INSERT INTO kept (id) VALUES (1)")
+	assert.NoError(t, err, "the transaction started before the reconnect goes on")
+	require.NoError(t, tx.Commit())
+
+	var count int
+	require.NoError(t, held.PingContext(context.Background()), "a handle taken before the reconnect still works")
+	require.NoError(t, held.Get(&count, "SELECT COUNT(*) FROM kept"))
+	assert.Equal(t, 1, count)
+}
+
+func TestReconnectOptions(t *testing.T) {
+	defaults := ReconnectOptions{}.withDefaults()
+	assert.Equal(t, DefaultReconnectAttempts, defaults.MaxAttempts)
+	assert.Equal(t, DefaultReconnectInitialBackoff, defaults.InitialBackoff)
+
+	for range 100 {
+		wait := defaults.backoff(time.Second)
+		assert.InDelta(t, float64(time.Second), float64(wait), float64(time.Second)*DefaultReconnectJitter)
+	}
+
+	assert.Empty(t, ReconnectOptions{}.validate())
+	assert.Len(t, ReconnectOptions{InitialBackoff: -1, Multiplier: 0.5, Jitter: 2}.validate(), 3)
+}
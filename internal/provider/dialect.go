@@ -0,0 +1,172 @@
+package provider
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// PlaceholderStyle is how a driver writes the bind parameters
+type PlaceholderStyle int
+
+const (
+	// PlaceholderQuestion writes every parameter as ?
+	PlaceholderQuestion PlaceholderStyle = iota
+
+	// PlaceholderDollar numbers the parameters $1, $2...
+	PlaceholderDollar
+
+	// PlaceholderColon numbers the parameters :p1, :p2...
+	PlaceholderColon
+)
+
+// LimitStyle is how a driver limits the rows of a query
+type LimitStyle int
+
+const (
+	// LimitOffset writes LIMIT n OFFSET m
+	LimitOffset LimitStyle = iota
+
+	// OffsetFetch writes OFFSET m ROWS FETCH NEXT n ROWS ONLY
+	OffsetFetch
+)
+
+// Dialect describes the SQL of a driver to the SQL builders, the zero value is the ? placeholders,
+// LIMIT/OFFSET and double quoted identifiers of SQLite
+type Dialect struct {
+	Placeholder PlaceholderStyle
+	Limit       LimitStyle
+
+	// IdentifierQuote quotes identifiers, double quotes when empty
+	IdentifierQuote string
+}
+
+var (
+	// SQLiteDialect is the dialect of the sqlite and memory drivers
+	SQLiteDialect = Dialect{Placeholder: PlaceholderQuestion, Limit: LimitOffset, IdentifierQuote: `"`}
+
+	// PostgresDialect is the dialect of the postgres driver
+	PostgresDialect = Dialect{Placeholder: PlaceholderDollar, Limit: LimitOffset, IdentifierQuote: `"`}
+
+	// MySQLDialect is the dialect of the mysql driver
+	MySQLDialect = Dialect{Placeholder: PlaceholderQuestion, Limit: LimitOffset, IdentifierQuote: "`"}
+
+	// OracleDialect is the dialect of the oracle driver
+	OracleDialect = Dialect{Placeholder: PlaceholderColon, Limit: OffsetFetch, IdentifierQuote: `"`}
+)
+
+// dialects holds the dialect of every known driver, the built-in ones are known even when their provider
+// is not compiled so options and SQL can still be checked and built
+var dialects = struct {
+	sync.RWMutex
+	m map[string]Dialect
+}{m: map[string]Dialect{
+	SQLiteDataProviderName:          SQLiteDialect,
+	MemoryDataProviderName:          SQLiteDialect,
+	PostgresSQLDatabaseProviderName: PostgresDialect,
+	MySQLDatabaseProviderName:       MySQLDialect,
+	OracleDatabaseProviderName:      OracleDialect,
+}}
+
+// RegisterDialect sets the dialect of a driver and makes its name valid for Options.Validate
+func RegisterDialect(driver string, d Dialect) {
+	dialects.Lock()
+	defer dialects.Unlock()
+	dialects.m[driver] = d
+}
+
+// DialectFor returns the dialect of a driver, the zero Dialect for an unknown one
+func DialectFor(driver string) Dialect {
+	d, _ := lookupDialect(driver)
+	return d
+}
+
+// lookupDialect returns the dialect of a driver and whether the driver is known
+func lookupDialect(driver string) (Dialect, bool) {
+	dialects.RLock()
+	defer dialects.RUnlock()
+	d, ok := dialects.m[driver]
+	return d, ok
+}
+
+// Bind returns the n-th bind parameter, counting from 1
+func (d Dialect) Bind(n int) string {
+	switch d.Placeholder {
+	case PlaceholderDollar:
+		return fmt.Sprintf("$%d", n)
+	case PlaceholderColon:
+		return fmt.Sprintf(":p%d", n)
+	default:
+		return "?"
+	}
+}
+
+// Rebind rewrites the ? parameters of query in the style of the dialect
+func (d Dialect) Rebind(query string) string {
+	if d.Placeholder == PlaceholderQuestion {
+		return query
+	}
+
+	var sb strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			sb.WriteString(d.Bind(n))
+			continue
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// LimitClause returns the clause limiting the rows of a query, limit and offset are left out when they
+// are not positive and the clause is empty when both are
+func (d Dialect) LimitClause(limit, offset int) string {
+	var l, o *int
+	if limit > 0 {
+		l = &limit
+	}
+	if offset > 0 {
+		o = &offset
+	}
+	return d.RowsClause(l, o)
+}
+
+// RowsClause returns the clause limiting the rows of a query, a nil limit or offset is left out and a
+// zero one is written so Limit(0) returns no rows
+func (d Dialect) RowsClause(limit, offset *int) string {
+	var parts []string
+
+	switch d.Limit {
+	case OffsetFetch:
+		if limit != nil || offset != nil {
+			var o int
+			if offset != nil {
+				o = max(*offset, 0)
+			}
+			parts = append(parts, fmt.Sprintf("OFFSET %d ROWS", o))
+		}
+		if limit != nil {
+			parts = append(parts, fmt.Sprintf("FETCH NEXT %d ROWS ONLY", *limit))
+		}
+	default:
+		if limit != nil {
+			parts = append(parts, fmt.Sprintf("LIMIT %d", *limit))
+		}
+		if offset != nil {
+			parts = append(parts, fmt.Sprintf("OFFSET %d", *offset))
+		}
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// Quote quotes an identifier, the quote character is doubled inside it
+func (d Dialect) Quote(identifier string) string {
+	q := d.IdentifierQuote
+	if q == "" {
+		q = `"`
+	}
+	return q + strings.ReplaceAll(identifier, q, q+q) + q
+}
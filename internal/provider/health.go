@@ -0,0 +1,155 @@
+package provider
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultHealthTimeout bounds CheckAvailability when Options.HealthTimeout is not set
+	DefaultHealthTimeout = 5 * time.Second
+
+	// DefaultHealthInterval is the time between two checks of a HealthMonitor created without interval
+	DefaultHealthInterval = 10 * time.Second
+)
+
+// healthTimeout returns the timeout of a health check
+func (o *Options) healthTimeout() time.Duration {
+	if o.HealthTimeout > 0 {
+		return o.HealthTimeout
+	}
+	return DefaultHealthTimeout
+}
+
+// HealthEvent is a change of the health of a provider
+type HealthEvent struct {
+	Healthy bool
+	Err     error
+	At      time.Time
+}
+
+// HealthMonitor checks a provider periodically and calls its callbacks when the health changes, the
+// first check always produces an event
+type HealthMonitor struct {
+	check    func() error
+	interval time.Duration
+
+	mu       sync.Mutex
+	checked  bool
+	healthy  bool
+	err      error
+	onChange []func(HealthEvent)
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+// NewHealthMonitor creates a monitor running check every interval, DefaultHealthInterval is used when
+// interval is not positive
+func NewHealthMonitor(check func() error, interval time.Duration) *HealthMonitor {
+	if interval <= 0 {
+		interval = DefaultHealthInterval
+	}
+	return &HealthMonitor{check: check, interval: interval}
+}
+
+// OnChange registers a callback called from the monitor goroutine when the health changes
+func (m *HealthMonitor) OnChange(fn func(HealthEvent)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onChange = append(m.onChange, fn)
+}
+
+// Start runs the first check and keeps checking in the background until ctx is done or Stop is called,
+// starting a running monitor does nothing and a stopped one can be started again
+func (m *HealthMonitor) Start(ctx context.Context) {
+	m.mu.Lock()
+	if m.done != nil {
+		m.mu.Unlock()
+		return
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	m.cancel, m.done = cancel, done
+	m.mu.Unlock()
+
+	go func() {
+		defer close(done)
+		defer m.stopped(done, cancel)
+
+		ticker := time.NewTicker(m.interval)
+		defer ticker.Stop()
+
+		for {
+			m.Check()
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// stopped forgets the goroutine closing done when it exits on its own because the parent context is
+// done, so the monitor can be started again
+func (m *HealthMonitor) stopped(done chan struct{}, cancel context.CancelFunc) {
+	m.mu.Lock()
+	if m.done == done {
+		m.cancel, m.done = nil, nil
+	}
+	m.mu.Unlock()
+
+	cancel()
+}
+
+// Stop stops the background checks and waits for the running one to finish
+func (m *HealthMonitor) Stop() {
+	m.mu.Lock()
+	cancel, done := m.cancel, m.done
+	m.cancel, m.done = nil, nil
+	m.mu.Unlock()
+
+	if cancel == nil {
+		return
+	}
+
+	cancel()
+	<-done
+}
+
+// Check runs a check now and calls the callbacks when the health changed
+func (m *HealthMonitor) Check() HealthEvent {
+	err := m.check()
+	event := HealthEvent{Healthy: err == nil, Err: err, At: time.Now()}
+
+	m.mu.Lock()
+	changed := !m.checked || m.healthy != event.Healthy
+	m.checked, m.healthy, m.err = true, event.Healthy, err
+	callbacks := m.onChange
+	m.mu.Unlock()
+
+	if changed {
+		for _, fn := range callbacks {
+			fn(event)
+		}
+	}
+
+	return event
+}
+
+// Healthy reports the result of the last check, false before the first one
+func (m *HealthMonitor) Healthy() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.healthy
+}
+
+// Err returns the error of the last check
+func (m *HealthMonitor) Err() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.err
+}
@@ -0,0 +1,39 @@
+package provider
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHealthMonitorRestart(t *testing.T) {
+	var checks atomic.Int32
+	monitor := NewHealthMonitor(func() error {
+		checks.Add(1)
+		return nil
+	}, time.Hour)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	monitor.Start(ctx)
+	assert.Eventually(t, func() bool { return checks.Load() == 1 }, time.Second, time.Millisecond)
+
+	// the monitor stops with its parent context and can be started again
+	cancel()
+	assert.Eventually(t, func() bool {
+		monitor.mu.Lock()
+		defer monitor.mu.Unlock()
+		return monitor.done == nil
+	}, time.Second, time.Millisecond)
+
+	monitor.Start(context.Background())
+	defer monitor.Stop()
+	assert.Eventually(t, func() bool { return checks.Load() == 2 }, time.Second, time.Millisecond)
+
+	// starting a running monitor does nothing
+	monitor.Start(context.Background())
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, int32(2), checks.Load())
+}
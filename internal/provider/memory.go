@@ -10,90 +10,142 @@ import (
 
 // MemoryProvider defines the auth provider for in-memory database
 type MemoryProvider struct {
-	dbHandle *sqlx.DB
-	context.Context
+	conn       *connection
+	options    *Options
+	initSchema string
+
+	// migration is the engine of the provider, it keeps the Go migrations registered on it
+	migration migration.Migration
 }
 
 func (m *MemoryProvider) SqlBuilder() *SQLBuilder {
-	return NewSQLBuilder(m.GetProviderStatus().Driver)
+	return NewSQLBuilder(m.options.Driver)
 }
 
 // GetProviderStatus returns the status of the provider
 func (m *MemoryProvider) GetProviderStatus() Status {
-	status := Status{
-		Driver:   driverName,
-		IsActive: true,
-	}
-
-	if err := m.CheckAvailability(); err != nil {
-		status.IsActive = false
-		status.Error = err
-	}
+	return m.GetProviderStatusContext(m.options.Context)
+}
 
-	return status
+// GetProviderStatusContext returns the status of the provider, ctx bounds the availability check
+func (m *MemoryProvider) GetProviderStatusContext(ctx context.Context) Status {
+	return m.conn.status(ctx, m.CheckAvailabilityContext(ctx))
 }
 
-// MigrateDatabase migrates the database to the latest version
+// MigrateDatabase returns the migration engine, it runs with the options context
 func (m *MemoryProvider) MigrateDatabase() migration.Migration {
-	// TODO implement me
-	panic("implement me")
+	return m.MigrateDatabaseContext(m.options.Context)
 }
 
-// Disconnect disconnects from the data provider
+// MigrateDatabaseContext returns the migration engine, it runs with ctx
+func (m *MemoryProvider) MigrateDatabaseContext(ctx context.Context) migration.Migration {
+	return m.migration.WithContext(ctx)
+}
+
+// Disconnect waits up to the shutdown timeout for the connections in use, then closes the pool
 func (m *MemoryProvider) Disconnect() error {
-	return m.dbHandle.Close()
+	ctx, cancel := context.WithTimeout(m.options.Context, m.options.shutdownTimeout())
+	defer cancel()
+
+	return m.DisconnectContext(ctx)
+}
+
+// DisconnectContext stops accepting work through the provider and closes the pool once the connections
+// in use are released or ctx is done
+func (m *MemoryProvider) DisconnectContext(ctx context.Context) error {
+	return m.conn.shutdown(ctx)
 }
 
 // GetConnection returns the connection to the data provider
 func (m *MemoryProvider) GetConnection() *sqlx.DB {
-	return m.dbHandle
+	return m.conn.db()
 }
 
 // CheckAvailability checks if the data provider is available
 func (m *MemoryProvider) CheckAvailability() error {
-	ctx, cancel := context.WithTimeout(m.Context, 5)
+	return m.CheckAvailabilityContext(m.options.Context)
+}
+
+// CheckAvailabilityContext checks if the data provider is available within ctx and the health timeout
+func (m *MemoryProvider) CheckAvailabilityContext(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, m.options.healthTimeout())
 	defer cancel()
 
-	return m.dbHandle.PingContext(ctx)
+	return m.conn.check(ctx, m.options.HealthQuery)
 }
 
 // ReconnectDatabase reconnects to the database
 func (m *MemoryProvider) ReconnectDatabase() error {
-	return m.CheckAvailability()
+	return m.ReconnectDatabaseContext(m.options.Context)
+}
+
+// ReconnectDatabaseContext reconnects to the database, ctx cancels the retries
+func (m *MemoryProvider) ReconnectDatabaseContext(ctx context.Context) error {
+	return m.conn.reconnect(ctx, m.options.Reconnect)
 }
 
 // InitializeDatabase initializes the database
 func (m *MemoryProvider) InitializeDatabase(schema string) error {
-	_, err := m.dbHandle.Exec(schema)
-	return err
+	return m.InitializeDatabaseContext(m.options.Context, schema)
+}
+
+// InitializeDatabaseContext initializes the database with ctx
+func (m *MemoryProvider) InitializeDatabaseContext(ctx context.Context, schema string) error {
+	if err := m.conn.err(); err != nil {
+		return err
+	}
+
+	if _, err := m.GetConnection().ExecContext(ctx, schema); err != nil {
+		return err
+	}
+
+	m.initSchema = schema
+	return nil
 }
 
-// RevertDatabase migrates the database to the latest version
+// RevertDatabase reverts the database to the specified version
 func (m *MemoryProvider) RevertDatabase(targetVersion int) error {
-	// TODO implement me
-	panic("implement me")
+	return m.RevertDatabaseContext(m.options.Context, targetVersion)
+}
+
+// RevertDatabaseContext reverts the database to the specified version with ctx
+func (m *MemoryProvider) RevertDatabaseContext(ctx context.Context, targetVersion int) error {
+	if err := m.conn.err(); err != nil {
+		return err
+	}
+
+	_, err := m.MigrateDatabaseContext(ctx).RevertTo(targetVersion)
+	return err
 }
 
 // ResetDatabase resets the database
 func (m *MemoryProvider) ResetDatabase() error {
-	// TODO implement me
-	panic("implement me")
+	return m.ResetDatabaseContext(m.options.Context)
+}
+
+// ResetDatabaseContext resets the database with ctx
+func (m *MemoryProvider) ResetDatabaseContext(ctx context.Context) error {
+	if err := m.conn.err(); err != nil {
+		return err
+	}
+
+	if err := resetSQLite(ctx, m.GetConnection(), m.options); err != nil {
+		return err
+	}
+
+	return reapplySchema(ctx, m, m.options, m.initSchema)
 }
 
 // NewMemoryProvider creates a new memory provider instance
 func NewMemoryProvider(options *Options) (*MemoryProvider, error) {
-	driverName = options.Driver
-	dbHandle, err := sqlx.Open("sqlite", options.ConnectionString)
+	conn, err := newConnection(options.Context, "sqlite", options)
 	if err != nil {
 		return nil, err
 	}
 
-	if err = dbHandle.PingContext(options.Context); err != nil {
-		return nil, err
-	}
-
 	return &MemoryProvider{
-		dbHandle: dbHandle,
-		Context:  options.Context,
+		conn:      conn,
+		options:   options,
+		migration: newMigration(options.Context, conn, options),
 	}, nil
 }
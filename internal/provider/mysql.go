@@ -4,100 +4,210 @@ package provider
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 
-	_ "github.com/go-sql-driver/mysql"
+	"github.com/go-sql-driver/mysql"
 	"github.com/inovacc/dataprovider/internal/migration"
 	"github.com/jmoiron/sqlx"
 )
 
 // MySQLProvider defines the auth provider for MySQL/MariaDB database
 type MySQLProvider struct {
-	dbHandle *sqlx.DB
-	context.Context
+	conn       *connection
+	options    *Options
+	initSchema string
+
+	// migration is the engine of the provider, it keeps the Go migrations registered on it
+	migration migration.Migration
 }
 
-func (m *MySQLProvider) NewSQLBuilder() *SQLBuilder {
-	return NewSQLBuilder(m.GetProviderStatus().Driver)
+func (m *MySQLProvider) SqlBuilder() *SQLBuilder {
+	return NewSQLBuilder(m.options.Driver)
 }
 
+// GetProviderStatus returns the status of the provider
 func (m *MySQLProvider) GetProviderStatus() Status {
-	status := Status{
-		Driver:   driverName,
-		IsActive: true,
-	}
-
-	if err := m.CheckAvailability(); err != nil {
-		status.IsActive = false
-		status.Error = err
-	}
+	return m.GetProviderStatusContext(m.options.Context)
+}
 
-	return status
+// GetProviderStatusContext returns the status of the provider, ctx bounds the availability check
+func (m *MySQLProvider) GetProviderStatusContext(ctx context.Context) Status {
+	return m.conn.status(ctx, m.CheckAvailabilityContext(ctx))
 }
 
+// MigrateDatabase returns the migration engine, it runs with the options context
 func (m *MySQLProvider) MigrateDatabase() migration.Migration {
-	// TODO implement me
-	panic("implement me")
+	return m.MigrateDatabaseContext(m.options.Context)
+}
+
+// MigrateDatabaseContext returns the migration engine, it runs with ctx
+func (m *MySQLProvider) MigrateDatabaseContext(ctx context.Context) migration.Migration {
+	return m.migration.WithContext(ctx)
 }
 
+// Disconnect waits up to the shutdown timeout for the connections in use, then closes the pool
 func (m *MySQLProvider) Disconnect() error {
-	return m.dbHandle.Close()
+	ctx, cancel := context.WithTimeout(m.options.Context, m.options.shutdownTimeout())
+	defer cancel()
+
+	return m.DisconnectContext(ctx)
+}
+
+// DisconnectContext stops accepting work through the provider and closes the pool once the connections
+// in use are released or ctx is done
+func (m *MySQLProvider) DisconnectContext(ctx context.Context) error {
+	return m.conn.shutdown(ctx)
 }
 
+// GetConnection returns the connection to the data provider
 func (m *MySQLProvider) GetConnection() *sqlx.DB {
-	return m.dbHandle
+	return m.conn.db()
 }
 
+// CheckAvailability checks if the data provider is available
 func (m *MySQLProvider) CheckAvailability() error {
-	ctx, cancel := context.WithTimeout(m.Context, 5)
+	return m.CheckAvailabilityContext(m.options.Context)
+}
+
+// CheckAvailabilityContext checks if the data provider is available within ctx and the health timeout
+func (m *MySQLProvider) CheckAvailabilityContext(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, m.options.healthTimeout())
 	defer cancel()
 
-	return m.dbHandle.PingContext(ctx)
+	return m.conn.check(ctx, m.options.HealthQuery)
 }
 
+// ReconnectDatabase reconnects to the database
 func (m *MySQLProvider) ReconnectDatabase() error {
-	return m.CheckAvailability()
+	return m.ReconnectDatabaseContext(m.options.Context)
 }
 
+// ReconnectDatabaseContext reconnects to the database, ctx cancels the retries
+func (m *MySQLProvider) ReconnectDatabaseContext(ctx context.Context) error {
+	return m.conn.reconnect(ctx, m.options.Reconnect)
+}
+
+// InitializeDatabase initializes the database
 func (m *MySQLProvider) InitializeDatabase(schema string) error {
-	_, err := m.dbHandle.Exec(schema)
-	return err
+	return m.InitializeDatabaseContext(m.options.Context, schema)
 }
 
+// InitializeDatabaseContext initializes the database with ctx
+func (m *MySQLProvider) InitializeDatabaseContext(ctx context.Context, schema string) error {
+	if err := m.conn.err(); err != nil {
+		return err
+	}
+
+	if _, err := m.GetConnection().ExecContext(ctx, schema); err != nil {
+		return err
+	}
+
+	m.initSchema = schema
+	return nil
+}
+
+// RevertDatabase reverts the database to the specified version
 func (m *MySQLProvider) RevertDatabase(targetVersion int) error {
-	// TODO implement me
-	panic("implement me")
+	return m.RevertDatabaseContext(m.options.Context, targetVersion)
+}
+
+// RevertDatabaseContext reverts the database to the specified version with ctx
+func (m *MySQLProvider) RevertDatabaseContext(ctx context.Context, targetVersion int) error {
+	if err := m.conn.err(); err != nil {
+		return err
+	}
+
+	_, err := m.MigrateDatabaseContext(ctx).RevertTo(targetVersion)
+	return err
 }
 
+// ResetDatabase resets the database
 func (m *MySQLProvider) ResetDatabase() error {
-	// TODO implement me
-	panic("implement me")
+	return m.ResetDatabaseContext(m.options.Context)
+}
+
+// ResetDatabaseContext resets the database with ctx
+func (m *MySQLProvider) ResetDatabaseContext(ctx context.Context) error {
+	if err := m.conn.err(); err != nil {
+		return err
+	}
+
+	if err := resetMySQL(ctx, m.GetConnection(), m.options); err != nil {
+		return err
+	}
+
+	return reapplySchema(ctx, m, m.options, m.initSchema)
 }
 
 // NewMySQLProvider creates a new MySQL provider instance
 func NewMySQLProvider(options *Options) (*MySQLProvider, error) {
-	driverName = options.Driver
-	dataSourceName := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s",
-		options.Username, options.Password, options.Host, options.Port, options.Name)
+	if options.TLS.enabled() {
+		tlsConfig, err := options.TLS.Config()
+		if err != nil {
+			return nil, err
+		}
+
+		if err = mysql.RegisterTLSConfig(options.TLS.mysqlTLSName(), tlsConfig); err != nil {
+			return nil, err
+		}
+	}
 
-	dbHandle, err := sqlx.Connect("mysql", dataSourceName)
+	conn, err := newConnection(options.Context, "mysql", options)
 	if err != nil {
 		return nil, err
 	}
 
-	dbHandle.SetMaxOpenConns(options.PoolSize)
-	if options.PoolSize > 0 {
-		dbHandle.SetMaxIdleConns(options.PoolSize)
-	} else {
-		dbHandle.SetMaxIdleConns(2)
+	return &MySQLProvider{
+		conn:      conn,
+		options:   options,
+		migration: newMigration(options.Context, conn, options),
+	}, nil
+}
+
+// resetMySQL drops every table and view of the MySQL schema, indexes go with their tables
+func resetMySQL(ctx context.Context, dbHandle *sqlx.DB, options *Options) error {
+	schema := options.Schema
+	if schema == "" {
+		schema = options.Name
 	}
 
-	if err = dbHandle.PingContext(options.Context); err != nil {
-		return nil, err
+	conn, err := dbHandle.Connx(ctx)
+	if err != nil {
+		return err
 	}
+	defer func() { _ = conn.Close() }()
 
-	return &MySQLProvider{
-		dbHandle: dbHandle,
-		Context:  options.Context,
-	}, nil
+	var rows []struct {
+		Name string `db:"TABLE_NAME"`
+		Type string `db:"TABLE_TYPE"`
+	}
+	query := "SELECT TABLE_NAME, TABLE_TYPE FROM information_schema.TABLES WHERE TABLE_SCHEMA = ?"
+	if err = conn.SelectContext(ctx, &rows, query, schema); err != nil {
+		return err
+	}
+
+	objects := make([]dbObject, 0, len(rows))
+	for _, row := range rows {
+		kind := objectTable
+		if row.Type == "VIEW" {
+			kind = objectView
+		}
+		objects = append(objects, dbObject{kind: kind, name: row.Name})
+	}
+
+	if _, err = conn.ExecContext(ctx, "SET FOREIGN_KEY_CHECKS = 0"); err != nil {
+		return err
+	}
+
+	err = dropObjects(ctx, conn, filterObjects(objects, options.SQLTablesPrefix), func(obj dbObject) string {
+		return fmt.Sprintf("DROP %s IF EXISTS %s.%s", strings.ToUpper(obj.kind), MySQLDialect.Quote(schema), MySQLDialect.Quote(obj.name))
+	})
+
+	if _, fkErr := conn.ExecContext(ctx, "SET FOREIGN_KEY_CHECKS = 1"); fkErr != nil {
+		return errors.Join(err, fkErr)
+	}
+
+	return err
 }
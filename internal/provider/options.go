@@ -2,6 +2,10 @@ package provider
 
 import (
 	"context"
+	"time"
+
+	"github.com/inovacc/dataprovider/internal/migration"
+	"github.com/spf13/afero"
 )
 
 type Options struct {
@@ -14,6 +18,21 @@ type Options struct {
 	Schema           string
 	SQLTablesPrefix  string
 	PoolSize         int
+	Pool             PoolOptions
 	ConnectionString string
+	Params           map[string]string
+	TLS              TLSOptions
+	HealthTimeout    time.Duration
+	HealthQuery      string
+	Reconnect        ReconnectOptions
+	ShutdownTimeout  time.Duration
+	MigrationsPath   string
+	MigrationsFs     afero.Fs
+	GoMigrations     []migration.GoMigration
+	ReapplyOnReset   bool
+
+	// MigrationLockStaleAfter is the age after which a SQLite migration lock is reported as stale,
+	// migration.DefaultStaleLockAfter when zero and never when negative
+	MigrationLockStaleAfter time.Duration
 	context.Context
 }
@@ -5,6 +5,7 @@ package provider
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	_ "github.com/godror/godror"
 	"github.com/inovacc/dataprovider/internal/migration"
@@ -13,88 +14,181 @@ import (
 
 // ORASQLProvider defines the auth provider for Oracle database
 type ORASQLProvider struct {
-	dbHandle *sqlx.DB
-	context.Context
+	conn       *connection
+	options    *Options
+	initSchema string
+
+	// migration is the engine of the provider, it keeps the Go migrations registered on it
+	migration migration.Migration
+}
+
+func (o *ORASQLProvider) SqlBuilder() *SQLBuilder {
+	return NewSQLBuilder(o.options.Driver)
+}
+
+// GetProviderStatus returns the status of the provider
+func (o *ORASQLProvider) GetProviderStatus() Status {
+	return o.GetProviderStatusContext(o.options.Context)
 }
 
-func (o *ORASQLProvider) NewSQLBuilder() *SQLBuilder {
-	return NewSQLBuilder(o.GetProviderStatus().Driver)
+// GetProviderStatusContext returns the status of the provider, ctx bounds the availability check
+func (o *ORASQLProvider) GetProviderStatusContext(ctx context.Context) Status {
+	return o.conn.status(ctx, o.CheckAvailabilityContext(ctx))
 }
 
+// MigrateDatabase returns the migration engine, it runs with the options context
 func (o *ORASQLProvider) MigrateDatabase() migration.Migration {
-	// TODO implement me
-	panic("implement me")
+	return o.MigrateDatabaseContext(o.options.Context)
 }
 
+// MigrateDatabaseContext returns the migration engine, it runs with ctx
+func (o *ORASQLProvider) MigrateDatabaseContext(ctx context.Context) migration.Migration {
+	return o.migration.WithContext(ctx)
+}
+
+// Disconnect waits up to the shutdown timeout for the connections in use, then closes the pool
 func (o *ORASQLProvider) Disconnect() error {
-	// TODO implement me
-	panic("implement me")
+	ctx, cancel := context.WithTimeout(o.options.Context, o.options.shutdownTimeout())
+	defer cancel()
+
+	return o.DisconnectContext(ctx)
 }
 
+// DisconnectContext stops accepting work through the provider and closes the pool once the connections
+// in use are released or ctx is done
+func (o *ORASQLProvider) DisconnectContext(ctx context.Context) error {
+	return o.conn.shutdown(ctx)
+}
+
+// GetConnection returns the connection to the data provider
 func (o *ORASQLProvider) GetConnection() *sqlx.DB {
-	return o.dbHandle
+	return o.conn.db()
 }
 
+// CheckAvailability checks if the data provider is available
 func (o *ORASQLProvider) CheckAvailability() error {
-	ctx, cancel := context.WithTimeout(o.Context, 5)
+	return o.CheckAvailabilityContext(o.options.Context)
+}
+
+// CheckAvailabilityContext checks if the data provider is available within ctx and the health timeout
+func (o *ORASQLProvider) CheckAvailabilityContext(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, o.options.healthTimeout())
 	defer cancel()
 
-	return o.dbHandle.PingContext(ctx)
+	return o.conn.check(ctx, o.options.HealthQuery)
 }
 
+// ReconnectDatabase reconnects to the database
 func (o *ORASQLProvider) ReconnectDatabase() error {
-	return o.CheckAvailability()
+	return o.ReconnectDatabaseContext(o.options.Context)
+}
+
+// ReconnectDatabaseContext reconnects to the database, ctx cancels the retries
+func (o *ORASQLProvider) ReconnectDatabaseContext(ctx context.Context) error {
+	return o.conn.reconnect(ctx, o.options.Reconnect)
 }
 
+// InitializeDatabase initializes the database
 func (o *ORASQLProvider) InitializeDatabase(schema string) error {
-	// TODO implement me
-	panic("implement me")
+	return o.InitializeDatabaseContext(o.options.Context, schema)
+}
+
+// InitializeDatabaseContext initializes the database with ctx
+func (o *ORASQLProvider) InitializeDatabaseContext(ctx context.Context, schema string) error {
+	if err := o.conn.err(); err != nil {
+		return err
+	}
+
+	if _, err := o.GetConnection().ExecContext(ctx, schema); err != nil {
+		return err
+	}
+
+	o.initSchema = schema
+	return nil
 }
 
+// RevertDatabase reverts the database to the specified version
 func (o *ORASQLProvider) RevertDatabase(targetVersion int) error {
-	// TODO implement me
-	panic("implement me")
+	return o.RevertDatabaseContext(o.options.Context, targetVersion)
+}
+
+// RevertDatabaseContext reverts the database to the specified version with ctx
+func (o *ORASQLProvider) RevertDatabaseContext(ctx context.Context, targetVersion int) error {
+	if err := o.conn.err(); err != nil {
+		return err
+	}
+
+	_, err := o.MigrateDatabaseContext(ctx).RevertTo(targetVersion)
+	return err
 }
 
+// ResetDatabase resets the database
 func (o *ORASQLProvider) ResetDatabase() error {
-	// TODO implement me
-	panic("implement me")
+	return o.ResetDatabaseContext(o.options.Context)
 }
 
-func (o *ORASQLProvider) GetProviderStatus() Status {
-	status := Status{
-		Driver:   driverName,
-		IsActive: true,
+// ResetDatabaseContext resets the database with ctx
+func (o *ORASQLProvider) ResetDatabaseContext(ctx context.Context) error {
+	if err := o.conn.err(); err != nil {
+		return err
 	}
 
-	if err := o.CheckAvailability(); err != nil {
-		status.IsActive = false
-		status.Error = err
+	if err := resetOracle(ctx, o.GetConnection(), o.options); err != nil {
+		return err
 	}
 
-	return status
+	return reapplySchema(ctx, o, o.options, o.initSchema)
 }
 
 // NewOracleProvider creates a new Oracle provider instance
 func NewOracleProvider(options *Options) (*ORASQLProvider, error) {
-	driverName = options.Driver
-	dataSourceName := fmt.Sprintf("%s/%s@%s:%d/%s",
-		options.Username, options.Password, options.Host, options.Port, options.Name)
-
-	dbHandle, err := sqlx.Connect("godror", dataSourceName)
+	conn, err := newConnection(options.Context, "godror", options)
 	if err != nil {
 		return nil, err
 	}
 
-	dbHandle.SetMaxOpenConns(options.PoolSize * 2)
-	dbHandle.SetMaxIdleConns(options.PoolSize)
+	return &ORASQLProvider{
+		conn:      conn,
+		options:   options,
+		migration: newMigration(options.Context, conn, options),
+	}, nil
+}
 
-	if err = dbHandle.PingContext(options.Context); err != nil {
-		return nil, err
+// resetOracle drops every table, view and sequence of the Oracle schema, indexes and the ISEQ$$ sequences
+// of identity columns go with their tables
+func resetOracle(ctx context.Context, dbHandle *sqlx.DB, options *Options) error {
+	schema := strings.ToUpper(options.Schema)
+	if schema == "" {
+		schema = strings.ToUpper(options.Username)
 	}
 
-	return &ORASQLProvider{
-		dbHandle: dbHandle,
-		Context:  options.Context,
-	}, nil
+	conn, err := dbHandle.Connx(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = conn.Close() }()
+
+	var rows []struct {
+		Name string `db:"OBJECT_NAME"`
+		Type string `db:"OBJECT_TYPE"`
+	}
+	query := `SELECT object_name, object_type FROM all_objects
+		WHERE owner = :1 AND object_type IN ('TABLE', 'VIEW', 'SEQUENCE')
+		AND generated = 'N' AND object_name NOT LIKE 'BIN$%' AND object_name NOT LIKE 'ISEQ$$%'`
+	if err = conn.SelectContext(ctx, &rows, query, schema); err != nil {
+		return err
+	}
+
+	objects := make([]dbObject, 0, len(rows))
+	for _, row := range rows {
+		objects = append(objects, dbObject{kind: strings.ToLower(row.Type), name: row.Name})
+	}
+
+	return dropObjects(ctx, conn, filterObjects(objects, options.SQLTablesPrefix), func(obj dbObject) string {
+		stmt := fmt.Sprintf("DROP %s %s.%s", strings.ToUpper(obj.kind), OracleDialect.Quote(schema), OracleDialect.Quote(obj.name))
+		if obj.kind == objectTable {
+			stmt += " CASCADE CONSTRAINTS PURGE"
+		}
+		return stmt
+	})
 }
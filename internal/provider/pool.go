@@ -0,0 +1,100 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// DefaultSQLiteWALReaders is the default number of open connections of a SQLite database in WAL mode,
+// WAL lets readers run next to the single writer
+const DefaultSQLiteWALReaders = 4
+
+// PoolOptions tunes the connection pool of a provider. A zero value picks the driver default, a negative
+// value means unlimited for MaxOpenConns and the durations and no idle connection for MaxIdleConns
+type PoolOptions struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+}
+
+// defaultPools are the per-driver defaults, SQLite serializes writes on one connection and the memory
+// database keeps its connection forever because the database is gone when the last one closes
+var defaultPools = map[string]PoolOptions{
+	PostgresSQLDatabaseProviderName: {MaxOpenConns: 25, MaxIdleConns: 5, ConnMaxLifetime: 30 * time.Minute, ConnMaxIdleTime: 5 * time.Minute},
+	MySQLDatabaseProviderName:       {MaxOpenConns: 25, MaxIdleConns: 5, ConnMaxLifetime: 30 * time.Minute, ConnMaxIdleTime: 5 * time.Minute},
+	OracleDatabaseProviderName:      {MaxOpenConns: 25, MaxIdleConns: 5, ConnMaxLifetime: 30 * time.Minute, ConnMaxIdleTime: 5 * time.Minute},
+	SQLiteDataProviderName:          {MaxOpenConns: 1, MaxIdleConns: 1},
+	MemoryDataProviderName:          {MaxOpenConns: 1, MaxIdleConns: 1},
+}
+
+// validate checks the pool options for the driver
+func (p PoolOptions) validate(driver string) []error {
+	var errs []error
+
+	if p.MaxOpenConns > MaxPoolSize || p.MaxIdleConns > MaxPoolSize {
+		errs = append(errs, fmt.Errorf("%w: connections are limited to %d", ErrInvalidPoolSize, MaxPoolSize))
+	}
+
+	if p.MaxOpenConns > 0 && p.MaxIdleConns > p.MaxOpenConns {
+		errs = append(errs, fmt.Errorf("%w: %d idle connections is above %d open connections", ErrInvalidPoolSize, p.MaxIdleConns, p.MaxOpenConns))
+	}
+
+	if driver == MemoryDataProviderName && (p.MaxIdleConns < 0 || p.ConnMaxLifetime > 0 || p.ConnMaxIdleTime > 0) {
+		errs = append(errs, fmt.Errorf("%w: the memory database is dropped when its last connection closes", ErrInvalidPoolSize))
+	}
+
+	return errs
+}
+
+// pool resolves the pool settings, PoolSize sets the open and idle connections as it always did and
+// the explicit Pool fields override the defaults
+func (o *Options) pool(wal bool) PoolOptions {
+	p := defaultPools[o.Driver]
+	if wal {
+		p.MaxOpenConns, p.MaxIdleConns = DefaultSQLiteWALReaders, DefaultSQLiteWALReaders
+	}
+
+	if o.PoolSize > 0 {
+		p.MaxOpenConns, p.MaxIdleConns = o.PoolSize, o.PoolSize
+	}
+
+	if o.Pool.MaxOpenConns != 0 {
+		p.MaxOpenConns = o.Pool.MaxOpenConns
+	}
+	if o.Pool.MaxIdleConns != 0 {
+		p.MaxIdleConns = o.Pool.MaxIdleConns
+	}
+	if o.Pool.ConnMaxLifetime != 0 {
+		p.ConnMaxLifetime = o.Pool.ConnMaxLifetime
+	}
+	if o.Pool.ConnMaxIdleTime != 0 {
+		p.ConnMaxIdleTime = o.Pool.ConnMaxIdleTime
+	}
+
+	return p
+}
+
+// applyPool sets the pool settings on the handle, database/sql treats the negative values as documented
+// on PoolOptions
+func applyPool(dbHandle *sqlx.DB, p PoolOptions) {
+	dbHandle.SetMaxOpenConns(max(p.MaxOpenConns, 0))
+	dbHandle.SetMaxIdleConns(p.MaxIdleConns)
+	dbHandle.SetConnMaxLifetime(p.ConnMaxLifetime)
+	dbHandle.SetConnMaxIdleTime(p.ConnMaxIdleTime)
+}
+
+// sqliteWAL tells if the SQLite database is in WAL mode, either from a _pragma parameter or because the
+// file was switched to WAL before
+func sqliteWAL(ctx context.Context, dbHandle *sqlx.DB) (bool, error) {
+	var mode string
+	if err := dbHandle.GetContext(ctx, &mode, "PRAGMA journal_mode"); err != nil {
+		return false, fmt.Errorf("reading the journal mode: %w", err)
+	}
+
+	return strings.EqualFold(mode, "wal"), nil
+}
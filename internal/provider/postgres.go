@@ -5,6 +5,7 @@ package provider
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/inovacc/dataprovider/internal/migration"
 	"github.com/jmoiron/sqlx"
@@ -13,91 +14,178 @@ import (
 
 // PGSQLProvider defines the auth provider for PostgresSQL database
 type PGSQLProvider struct {
-	dbHandle *sqlx.DB
-	context.Context
+	conn       *connection
+	options    *Options
+	initSchema string
+
+	// migration is the engine of the provider, it keeps the Go migrations registered on it
+	migration migration.Migration
 }
 
-func (p *PGSQLProvider) NewSQLBuilder() *SQLBuilder {
-	return NewSQLBuilder(p.GetProviderStatus().Driver)
+func (p *PGSQLProvider) SqlBuilder() *SQLBuilder {
+	return NewSQLBuilder(p.options.Driver)
 }
 
+// GetProviderStatus returns the status of the provider
 func (p *PGSQLProvider) GetProviderStatus() Status {
-	status := Status{
-		Driver:   driverName,
-		IsActive: true,
-	}
-
-	if err := p.CheckAvailability(); err != nil {
-		status.IsActive = false
-		status.Error = err
-	}
+	return p.GetProviderStatusContext(p.options.Context)
+}
 
-	return status
+// GetProviderStatusContext returns the status of the provider, ctx bounds the availability check
+func (p *PGSQLProvider) GetProviderStatusContext(ctx context.Context) Status {
+	return p.conn.status(ctx, p.CheckAvailabilityContext(ctx))
 }
 
+// MigrateDatabase returns the migration engine, it runs with the options context
 func (p *PGSQLProvider) MigrateDatabase() migration.Migration {
-	// TODO implement me
-	panic("implement me")
+	return p.MigrateDatabaseContext(p.options.Context)
+}
+
+// MigrateDatabaseContext returns the migration engine, it runs with ctx
+func (p *PGSQLProvider) MigrateDatabaseContext(ctx context.Context) migration.Migration {
+	return p.migration.WithContext(ctx)
 }
 
+// Disconnect waits up to the shutdown timeout for the connections in use, then closes the pool
 func (p *PGSQLProvider) Disconnect() error {
-	return p.dbHandle.Close()
+	ctx, cancel := context.WithTimeout(p.options.Context, p.options.shutdownTimeout())
+	defer cancel()
+
+	return p.DisconnectContext(ctx)
 }
 
+// DisconnectContext stops accepting work through the provider and closes the pool once the connections
+// in use are released or ctx is done
+func (p *PGSQLProvider) DisconnectContext(ctx context.Context) error {
+	return p.conn.shutdown(ctx)
+}
+
+// GetConnection returns the connection to the data provider
 func (p *PGSQLProvider) GetConnection() *sqlx.DB {
-	return p.dbHandle
+	return p.conn.db()
 }
 
+// CheckAvailability checks if the data provider is available
 func (p *PGSQLProvider) CheckAvailability() error {
-	ctx, cancel := context.WithTimeout(p.Context, 5)
+	return p.CheckAvailabilityContext(p.options.Context)
+}
+
+// CheckAvailabilityContext checks if the data provider is available within ctx and the health timeout
+func (p *PGSQLProvider) CheckAvailabilityContext(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, p.options.healthTimeout())
 	defer cancel()
 
-	return p.dbHandle.PingContext(ctx)
+	return p.conn.check(ctx, p.options.HealthQuery)
 }
 
+// ReconnectDatabase reconnects to the database
 func (p *PGSQLProvider) ReconnectDatabase() error {
-	return p.CheckAvailability()
+	return p.ReconnectDatabaseContext(p.options.Context)
+}
+
+// ReconnectDatabaseContext reconnects to the database, ctx cancels the retries
+func (p *PGSQLProvider) ReconnectDatabaseContext(ctx context.Context) error {
+	return p.conn.reconnect(ctx, p.options.Reconnect)
 }
 
+// InitializeDatabase initializes the database
 func (p *PGSQLProvider) InitializeDatabase(schema string) error {
-	_, err := p.dbHandle.Exec(schema)
-	return err
+	return p.InitializeDatabaseContext(p.options.Context, schema)
+}
+
+// InitializeDatabaseContext initializes the database with ctx
+func (p *PGSQLProvider) InitializeDatabaseContext(ctx context.Context, schema string) error {
+	if err := p.conn.err(); err != nil {
+		return err
+	}
+
+	if _, err := p.GetConnection().ExecContext(ctx, schema); err != nil {
+		return err
+	}
+
+	p.initSchema = schema
+	return nil
 }
 
+// RevertDatabase reverts the database to the specified version
 func (p *PGSQLProvider) RevertDatabase(targetVersion int) error {
-	// TODO implement me
-	panic("implement me")
+	return p.RevertDatabaseContext(p.options.Context, targetVersion)
 }
 
+// RevertDatabaseContext reverts the database to the specified version with ctx
+func (p *PGSQLProvider) RevertDatabaseContext(ctx context.Context, targetVersion int) error {
+	if err := p.conn.err(); err != nil {
+		return err
+	}
+
+	_, err := p.MigrateDatabaseContext(ctx).RevertTo(targetVersion)
+	return err
+}
+
+// ResetDatabase resets the database
 func (p *PGSQLProvider) ResetDatabase() error {
-	// TODO implement me
-	panic("implement me")
+	return p.ResetDatabaseContext(p.options.Context)
+}
+
+// ResetDatabaseContext resets the database with ctx
+func (p *PGSQLProvider) ResetDatabaseContext(ctx context.Context) error {
+	if err := p.conn.err(); err != nil {
+		return err
+	}
+
+	if err := resetPostgres(ctx, p.GetConnection(), p.options); err != nil {
+		return err
+	}
+
+	return reapplySchema(ctx, p, p.options, p.initSchema)
 }
 
 // NewPostgresSQLProvider creates a new PostgresSQL provider instance
 func NewPostgresSQLProvider(options *Options) (*PGSQLProvider, error) {
-	driverName = options.Driver
-	dataSourceName := fmt.Sprintf("user=%s dbname=%s password=%s port=%d host=%s sslmode=disable",
-		options.Username, options.Name, options.Password, options.Port, options.Host)
-
-	dbHandle, err := sqlx.Connect("postgres", dataSourceName)
+	conn, err := newConnection(options.Context, "postgres", options)
 	if err != nil {
 		return nil, err
 	}
 
-	dbHandle.SetMaxOpenConns(options.PoolSize)
-	if options.PoolSize > 0 {
-		dbHandle.SetMaxIdleConns(options.PoolSize)
-	} else {
-		dbHandle.SetMaxIdleConns(2)
+	return &PGSQLProvider{
+		conn:      conn,
+		options:   options,
+		migration: newMigration(options.Context, conn, options),
+	}, nil
+}
+
+// resetPostgres drops every table, view and sequence of the PostgreSQL schema, indexes go with their tables
+func resetPostgres(ctx context.Context, dbHandle *sqlx.DB, options *Options) error {
+	schema := options.Schema
+	if schema == "" {
+		schema = "public"
 	}
 
-	if err = dbHandle.PingContext(options.Context); err != nil {
-		return nil, err
+	conn, err := dbHandle.Connx(ctx)
+	if err != nil {
+		return err
 	}
+	defer func() { _ = conn.Close() }()
 
-	return &PGSQLProvider{
-		dbHandle: dbHandle,
-		Context:  options.Context,
-	}, nil
+	var objects []dbObject
+	queries := map[string]string{
+		objectTable:    "SELECT tablename FROM pg_tables WHERE schemaname = $1",
+		objectView:     "SELECT viewname FROM pg_views WHERE schemaname = $1",
+		objectSequence: "SELECT sequence_name FROM information_schema.sequences WHERE sequence_schema = $1",
+	}
+
+	for kind, query := range queries {
+		var names []string
+		if err = conn.SelectContext(ctx, &names, query, schema); err != nil {
+			return err
+		}
+
+		for _, name := range names {
+			objects = append(objects, dbObject{kind: kind, name: name})
+		}
+	}
+
+	return dropObjects(ctx, conn, filterObjects(objects, options.SQLTablesPrefix), func(obj dbObject) string {
+		return fmt.Sprintf("DROP %s IF EXISTS %s.%s CASCADE", strings.ToUpper(obj.kind), PostgresDialect.Quote(schema), PostgresDialect.Quote(obj.name))
+	})
 }
@@ -1,5 +1,13 @@
 package provider
 
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/inovacc/dataprovider/internal/migration"
+)
+
 const (
 	// OracleDatabaseProviderName defines the name for Oracle database Provider
 	OracleDatabaseProviderName string = "oracle"
@@ -17,10 +25,55 @@ const (
 	MemoryDataProviderName string = "memory"
 )
 
-var driverName string
+// ErrDriverNotCompiled is matched by DriverNotCompiledError
+var ErrDriverNotCompiled = errors.New("driver is not compiled in")
+
+// buildTags are the build tags compiling the network providers in
+var buildTags = map[string]string{
+	PostgresSQLDatabaseProviderName: "postgres",
+	MySQLDatabaseProviderName:       "mysql",
+	OracleDatabaseProviderName:      "oracle",
+}
+
+// DriverNotCompiledError reports a built-in driver whose provider was left out of the build, Tag is the
+// build tag compiling it in
+type DriverNotCompiledError struct {
+	Driver string
+	Tag    string
+}
+
+func (e *DriverNotCompiledError) Error() string {
+	return fmt.Sprintf("%s %s: build with -tags %s", ErrDriverNotCompiled, e.Driver, e.Tag)
+}
+
+func (e *DriverNotCompiledError) Unwrap() error {
+	return ErrDriverNotCompiled
+}
+
+// NotCompiled returns a DriverNotCompiledError for a built-in driver that needs a build tag, nil for
+// any other driver
+func NotCompiled(driver string) error {
+	tag, ok := buildTags[driver]
+	if !ok {
+		return nil
+	}
+	return &DriverNotCompiledError{Driver: driver, Tag: tag}
+}
 
-type Status struct {
-	Driver   string `json:"driver"`
-	Error    error  `json:"error"`
-	IsActive bool   `json:"is_active"`
+// newMigration creates the migration engine for the given connection and options, it runs with ctx and
+// fails with ErrProviderClosed once the connection is closed
+func newMigration(ctx context.Context, conn *connection, options *Options) migration.Migration {
+	return migration.NewMigration(conn.db(), migration.Options{
+		Source: migration.Source{
+			Path:         options.MigrationsPath,
+			Fs:           options.MigrationsFs,
+			GoMigrations: options.GoMigrations,
+		},
+		Driver:         options.Driver,
+		Schema:         options.Schema,
+		TablePrefix:    options.SQLTablesPrefix,
+		Context:        ctx,
+		Check:          conn.err,
+		StaleLockAfter: options.MigrationLockStaleAfter,
+	})
 }
@@ -236,26 +236,19 @@ func (b *SQLBuilder) Build() string {
 		sb.WriteString(strings.Join(b.orderBy, ", "))
 	}
 
-	switch b.driver {
-	case OracleDatabaseProviderName:
-		if b.offset > 0 || b.limit > 0 {
-			sb.WriteString(fmt.Sprintf(" OFFSET %d ROWS", b.offset))
-			if b.limit > 0 {
-				sb.WriteString(fmt.Sprintf(" FETCH NEXT %d ROWS ONLY", b.limit))
-			}
-		}
-	default:
-		if b.limit > 0 {
-			sb.WriteString(fmt.Sprintf(" LIMIT %d", b.limit))
-		}
-		if b.offset > 0 {
-			sb.WriteString(fmt.Sprintf(" OFFSET %d", b.offset))
-		}
+	if limit := DialectFor(b.driver).LimitClause(b.limit, b.offset); limit != "" {
+		sb.WriteString(" ")
+		sb.WriteString(limit)
 	}
 
 	return sb.String()
 }
 
+// Quote quotes an identifier for the driver of the builder
+func (b *SQLBuilder) Quote(identifier string) string {
+	return DialectFor(b.driver).Quote(identifier)
+}
+
 func (b *SQLBuilder) Truncate() string {
 	var sb strings.Builder
 	sb.WriteString("TRUNCATE TABLE ")
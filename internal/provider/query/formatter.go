@@ -1,9 +1,6 @@
 package query
 
 import (
-	"fmt"
-	"strings"
-
 	"github.com/inovacc/dataprovider/internal/provider"
 )
 
@@ -12,37 +9,15 @@ type PlaceholderFormatter interface {
 	ReplacePlaceholders(query string) string
 }
 
-type postgresFormatter struct{}
-
-func (f *postgresFormatter) ReplacePlaceholders(query string) string {
-	for i := 1; strings.Contains(query, "?"); i++ {
-		query = strings.Replace(query, "?", fmt.Sprintf("$%d", i), 1)
-	}
-	return query
-}
-
-type oracleFormatter struct{}
-
-func (f *oracleFormatter) ReplacePlaceholders(query string) string {
-	for i := 1; strings.Contains(query, "?"); i++ {
-		query = strings.Replace(query, "?", fmt.Sprintf(":p%d", i), 1)
-	}
-	return query
+// dialectFormatter rewrites the placeholders with the dialect registered for the driver
+type dialectFormatter struct {
+	dialect provider.Dialect
 }
 
-type defaultFormatter struct{}
-
-func (f *defaultFormatter) ReplacePlaceholders(query string) string {
-	return query
+func (f *dialectFormatter) ReplacePlaceholders(query string) string {
+	return f.dialect.Rebind(query)
 }
 
 func NewFormatter(driver string) PlaceholderFormatter {
-	switch driver {
-	case provider.PostgresSQLDatabaseProviderName:
-		return &postgresFormatter{}
-	case provider.OracleDatabaseProviderName:
-		return &oracleFormatter{}
-	default:
-		return &defaultFormatter{}
-	}
+	return &dialectFormatter{dialect: provider.DialectFor(driver)}
 }
@@ -19,8 +19,6 @@ const (
 	whereTemplate       = " WHERE %s"
 	groupByTemplate     = "GROUP BY %s"
 	orderByTemplate     = "ORDER BY %s"
-	limitTemplate       = "LIMIT %d"
-	offsetTemplate      = "OFFSET %d"
 	havingTemplate      = "HAVING %s"
 	selectTemplate      = "SELECT %s FROM %s"
 	createTableTemplate = "CREATE TABLE %s (%s)"
@@ -567,12 +565,9 @@ func (b *queryBuilder) Build() (string, []any) {
 		sb.WriteString(fmt.Sprintf(orderByTemplate, strings.Join(b.orderBy, ", ")))
 	}
 
-	if b.limit != nil {
-		sb.WriteString(fmt.Sprintf(" %s", fmt.Sprintf(limitTemplate, *b.limit)))
-	}
-
-	if b.offset != nil {
-		sb.WriteString(fmt.Sprintf(" %s", fmt.Sprintf(offsetTemplate, *b.offset)))
+	if clause := provider.DialectFor(b.opts.Driver).RowsClause(b.limit, b.offset); clause != "" {
+		sb.WriteString(" ")
+		sb.WriteString(clause)
 	}
 
 	return b.formatter.ReplacePlaceholders(sb.String()), b.args
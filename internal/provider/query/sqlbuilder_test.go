@@ -15,7 +15,7 @@ func TestQueryBuilderVariants(t *testing.T) {
 	}{
 		{
 			driver:      provider.OracleDatabaseProviderName,
-			expectedSQL: "SELECT id, name FROM users WHERE status = :p1 ORDER BY name LIMIT 10",
+			expectedSQL: "SELECT id, name FROM users WHERE status = :p1 ORDER BY name OFFSET 0 ROWS FETCH NEXT 10 ROWS ONLY",
 		},
 		{
 			driver:      provider.PostgresSQLDatabaseProviderName,
@@ -527,3 +527,22 @@ data: null
 		t.Errorf("Expected YAML output: %q\nGot: %q", expected, yamlOut)
 	}
 }
+
+func TestQueryLimitZero(t *testing.T) {
+	tests := []struct {
+		driver      string
+		expectedSQL string
+	}{
+		{driver: provider.SQLiteDataProviderName, expectedSQL: "SELECT id FROM users LIMIT 0 OFFSET 0"},
+		{driver: provider.OracleDatabaseProviderName, expectedSQL: "SELECT id FROM users OFFSET 0 ROWS FETCH NEXT 0 ROWS ONLY"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.driver, func(t *testing.T) {
+			sql, _ := NewQueryBuilder(provider.Options{Driver: tt.driver}).Select("users", "id").Limit(0).Offset(0).Build()
+			if sql != tt.expectedSQL {
+				t.Errorf("driver %s: expected %q, got %q", tt.driver, tt.expectedSQL, sql)
+			}
+		})
+	}
+}
@@ -0,0 +1,251 @@
+package provider
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Node is the part of a provider a ReplicaSet routes to
+type Node interface {
+	GetConnection() *sqlx.DB
+	CheckAvailabilityContext(ctx context.Context) error
+	ReconnectDatabase() error
+	ReconnectDatabaseContext(ctx context.Context) error
+	Disconnect() error
+	DisconnectContext(ctx context.Context) error
+}
+
+// ReplicaPolicy picks the replica serving a read among the healthy ones
+type ReplicaPolicy interface {
+	// Pick returns the index in replicas of the handle to use, replicas is never empty
+	Pick(replicas []*sqlx.DB) int
+}
+
+// roundRobin cycles through the replicas
+type roundRobin struct {
+	next atomic.Uint64
+}
+
+// RoundRobin returns a policy sending the reads to each replica in turn
+func RoundRobin() ReplicaPolicy {
+	return &roundRobin{}
+}
+
+func (r *roundRobin) Pick(replicas []*sqlx.DB) int {
+	return int((r.next.Add(1) - 1) % uint64(len(replicas)))
+}
+
+// leastConnections picks the replica with the fewest connections in use
+type leastConnections struct{}
+
+// LeastConnections returns a policy sending the reads to the replica with the fewest connections in use,
+// the first one wins a tie
+func LeastConnections() ReplicaPolicy {
+	return leastConnections{}
+}
+
+func (leastConnections) Pick(replicas []*sqlx.DB) int {
+	best, inUse := 0, replicas[0].Stats().InUse
+	for i, r := range replicas[1:] {
+		if n := r.Stats().InUse; n < inUse {
+			best, inUse = i+1, n
+		}
+	}
+	return best
+}
+
+// ReplicaOptions configures a ReplicaSet, zero values pick RoundRobin and DefaultHealthInterval
+type ReplicaOptions struct {
+	Policy         ReplicaPolicy
+	HealthInterval time.Duration
+}
+
+// forcePrimaryKey marks a context whose reads go to the primary
+type forcePrimaryKey struct{}
+
+// ForcePrimary returns a context whose reads are served by the primary, for paths that must read their
+// own writes
+func ForcePrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, forcePrimaryKey{}, true)
+}
+
+// IsPrimaryForced tells if ctx was marked by ForcePrimary
+func IsPrimaryForced(ctx context.Context) bool {
+	forced, _ := ctx.Value(forcePrimaryKey{}).(bool)
+	return forced
+}
+
+// replica is a replica node with the monitor checking its health
+type replica struct {
+	node    Node
+	monitor *HealthMonitor
+	stopped atomic.Bool
+}
+
+// healthy tells if the replica serves reads, its monitor holds the result of the last check
+func (r *replica) healthy() bool {
+	return !r.stopped.Load() && r.monitor.Healthy()
+}
+
+// ReplicaSet routes writes and transactions to the primary and balances reads over the healthy
+// replicas, reads go to the primary when no replica is healthy
+type ReplicaSet struct {
+	primary  Node
+	replicas []*replica
+	policy   ReplicaPolicy
+	cancel   context.CancelFunc
+	stopOnce sync.Once
+}
+
+// NewReplicaSet checks the replicas once and keeps checking them in the background until it is
+// disconnected
+func NewReplicaSet(primary Node, replicas []Node, options ReplicaOptions) *ReplicaSet {
+	if options.Policy == nil {
+		options.Policy = RoundRobin()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	set := &ReplicaSet{primary: primary, policy: options.Policy, cancel: cancel}
+
+	for _, node := range replicas {
+		r := &replica{node: node}
+		r.monitor = NewHealthMonitor(func() error { return node.CheckAvailabilityContext(ctx) }, options.HealthInterval)
+		r.monitor.Check()
+		r.monitor.Start(ctx)
+
+		set.replicas = append(set.replicas, r)
+	}
+
+	return set
+}
+
+// Primary returns the connection of the primary
+func (s *ReplicaSet) Primary() *sqlx.DB {
+	return s.primary.GetConnection()
+}
+
+// Reader returns the connection serving a read with ctx, the primary when ctx is marked by ForcePrimary
+// or no replica is healthy
+func (s *ReplicaSet) Reader(ctx context.Context) *sqlx.DB {
+	if IsPrimaryForced(ctx) {
+		return s.Primary()
+	}
+
+	healthy := make([]*sqlx.DB, 0, len(s.replicas))
+	for _, r := range s.replicas {
+		if r.healthy() {
+			healthy = append(healthy, r.node.GetConnection())
+		}
+	}
+
+	if len(healthy) == 0 {
+		return s.Primary()
+	}
+
+	return healthy[s.policy.Pick(healthy)]
+}
+
+// HealthyReplicas returns the number of replicas serving reads
+func (s *ReplicaSet) HealthyReplicas() int {
+	var n int
+	for _, r := range s.replicas {
+		if r.healthy() {
+			n++
+		}
+	}
+	return n
+}
+
+// CheckReplicas checks every replica now instead of waiting for the next health check
+func (s *ReplicaSet) CheckReplicas() {
+	for _, r := range s.replicas {
+		r.monitor.Check()
+	}
+}
+
+// QueryxContext runs a read-only query on a replica
+func (s *ReplicaSet) QueryxContext(ctx context.Context, query string, args ...any) (*sqlx.Rows, error) {
+	return s.Reader(ctx).QueryxContext(ctx, query, args...)
+}
+
+// QueryRowxContext runs a read-only query returning one row on a replica
+func (s *ReplicaSet) QueryRowxContext(ctx context.Context, query string, args ...any) *sqlx.Row {
+	return s.Reader(ctx).QueryRowxContext(ctx, query, args...)
+}
+
+// GetContext scans one row of a read-only query run on a replica into dest
+func (s *ReplicaSet) GetContext(ctx context.Context, dest any, query string, args ...any) error {
+	return s.Reader(ctx).GetContext(ctx, dest, query, args...)
+}
+
+// SelectContext scans the rows of a read-only query run on a replica into dest
+func (s *ReplicaSet) SelectContext(ctx context.Context, dest any, query string, args ...any) error {
+	return s.Reader(ctx).SelectContext(ctx, dest, query, args...)
+}
+
+// ExecContext runs a write on the primary
+func (s *ReplicaSet) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return s.Primary().ExecContext(ctx, query, args...)
+}
+
+// NamedExecContext runs a write with named parameters on the primary
+func (s *ReplicaSet) NamedExecContext(ctx context.Context, query string, arg any) (sql.Result, error) {
+	return s.Primary().NamedExecContext(ctx, query, arg)
+}
+
+// BeginTxx starts a transaction on the primary
+func (s *ReplicaSet) BeginTxx(ctx context.Context, opts *sql.TxOptions) (*sqlx.Tx, error) {
+	return s.Primary().BeginTxx(ctx, opts)
+}
+
+// ReconnectDatabase reconnects the primary and the replicas with the options context of each one
+func (s *ReplicaSet) ReconnectDatabase() error {
+	return s.each(Node.ReconnectDatabase)
+}
+
+// ReconnectDatabaseContext reconnects the primary and the replicas
+func (s *ReplicaSet) ReconnectDatabaseContext(ctx context.Context) error {
+	return s.each(func(n Node) error { return n.ReconnectDatabaseContext(ctx) })
+}
+
+// Disconnect stops the health checks and disconnects the replicas, then the primary, each one waits up
+// to its own shutdown timeout
+func (s *ReplicaSet) Disconnect() error {
+	s.stop()
+	return s.each(Node.Disconnect)
+}
+
+// DisconnectContext stops the health checks and disconnects the replicas, then the primary
+func (s *ReplicaSet) DisconnectContext(ctx context.Context) error {
+	s.stop()
+	return s.each(func(n Node) error { return n.DisconnectContext(ctx) })
+}
+
+// stop stops the health checks, the replicas stop serving reads
+func (s *ReplicaSet) stop() {
+	s.stopOnce.Do(func() {
+		s.cancel()
+		for _, r := range s.replicas {
+			r.stopped.Store(true)
+			r.monitor.Stop()
+		}
+	})
+}
+
+// each calls fn on the replicas, then the primary, and joins the errors
+func (s *ReplicaSet) each(fn func(Node) error) error {
+	errs := make([]error, 0, len(s.replicas)+1)
+	for _, r := range s.replicas {
+		errs = append(errs, fn(r.node))
+	}
+	errs = append(errs, fn(s.primary))
+
+	s.CheckReplicas()
+	return errors.Join(errs...)
+}
@@ -0,0 +1,137 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/inovacc/dataprovider/internal/migration"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	objectView     = "view"
+	objectTable    = "table"
+	objectSequence = "sequence"
+)
+
+// dropOrder defines the order objects are dropped, views first since they depend on tables
+var dropOrder = map[string]int{
+	objectView:     0,
+	objectTable:    1,
+	objectSequence: 2,
+}
+
+// dbObject is a database object removed by ResetDatabase
+type dbObject struct {
+	kind string
+	name string
+}
+
+// hasTablePrefix reports if name belongs to the provider according to the tables prefix
+func hasTablePrefix(name, prefix string) bool {
+	return strings.HasPrefix(strings.ToLower(name), strings.ToLower(prefix))
+}
+
+// filterObjects keeps the objects owned by the provider and sorts them in drop order
+func filterObjects(objects []dbObject, prefix string) []dbObject {
+	owned := make([]dbObject, 0, len(objects))
+	for _, obj := range objects {
+		if hasTablePrefix(obj.name, prefix) {
+			owned = append(owned, obj)
+		}
+	}
+
+	sort.SliceStable(owned, func(i, j int) bool {
+		return dropOrder[owned[i].kind] < dropOrder[owned[j].kind]
+	})
+
+	return owned
+}
+
+// dropObjects executes the drop statement of every object on the same connection
+func dropObjects(ctx context.Context, conn *sqlx.Conn, objects []dbObject, dropStatement func(dbObject) string) error {
+	for _, obj := range objects {
+		if _, err := conn.ExecContext(ctx, dropStatement(obj)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// schemaInitializer is implemented by every provider to re-apply its schema after a reset
+type schemaInitializer interface {
+	InitializeDatabaseContext(ctx context.Context, schema string) error
+	MigrateDatabaseContext(ctx context.Context) migration.Migration
+}
+
+// reapplySchema runs the last initialization schema and the migrations, from files or Go, when the
+// options ask for it
+func reapplySchema(ctx context.Context, p schemaInitializer, options *Options, schema string) error {
+	if !options.ReapplyOnReset {
+		return nil
+	}
+
+	if schema != "" {
+		if err := p.InitializeDatabaseContext(ctx, schema); err != nil {
+			return err
+		}
+	}
+
+	// the migrations can be SQL files, Go migrations or both, none at all is not an error here
+	if err := p.MigrateDatabaseContext(ctx).Migrate(); err != nil && !errors.Is(err, migration.ErrNoMigrationsPath) {
+		return err
+	}
+
+	return nil
+}
+
+// resetSQLite drops every table and view of a SQLite database, indexes and triggers go with their tables
+func resetSQLite(ctx context.Context, dbHandle *sqlx.DB, options *Options) error {
+	schema := options.Schema
+	if schema == "" {
+		schema = "main"
+	}
+
+	conn, err := dbHandle.Connx(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = conn.Close() }()
+
+	var rows []struct {
+		Type string `db:"type"`
+		Name string `db:"name"`
+	}
+	query := "SELECT type, name FROM " + SQLiteDialect.Quote(schema) + ".sqlite_master WHERE type IN ('table', 'view') AND name NOT LIKE 'sqlite_%'"
+	if err = conn.SelectContext(ctx, &rows, query); err != nil {
+		return err
+	}
+
+	objects := make([]dbObject, 0, len(rows))
+	for _, row := range rows {
+		objects = append(objects, dbObject{kind: row.Type, name: row.Name})
+	}
+
+	var foreignKeys int
+	if err = conn.GetContext(ctx, &foreignKeys, "PRAGMA foreign_keys"); err != nil {
+		return err
+	}
+
+	if _, err = conn.ExecContext(ctx, "PRAGMA foreign_keys = OFF"); err != nil {
+		return err
+	}
+
+	err = dropObjects(ctx, conn, filterObjects(objects, options.SQLTablesPrefix), func(obj dbObject) string {
+		return "DROP " + strings.ToUpper(obj.kind) + " IF EXISTS " + SQLiteDialect.Quote(schema) + "." + SQLiteDialect.Quote(obj.name)
+	})
+
+	if foreignKeys == 1 {
+		if _, fkErr := conn.ExecContext(ctx, "PRAGMA foreign_keys = ON"); fkErr != nil {
+			return errors.Join(err, fkErr)
+		}
+	}
+
+	return err
+}
@@ -10,92 +10,142 @@ import (
 
 // SQLiteProvider defines the auth provider for SQLite database
 type SQLiteProvider struct {
-	dbHandle *sqlx.DB
-	context.Context
+	conn       *connection
+	options    *Options
+	initSchema string
+
+	// migration is the engine of the provider, it keeps the Go migrations registered on it
+	migration migration.Migration
 }
 
 func (s *SQLiteProvider) SqlBuilder() *SQLBuilder {
-	return NewSQLBuilder(s.GetProviderStatus().Driver)
+	return NewSQLBuilder(s.options.Driver)
 }
 
 // GetProviderStatus returns the status of the provider
 func (s *SQLiteProvider) GetProviderStatus() Status {
-	status := Status{
-		Driver:   driverName,
-		IsActive: true,
-	}
-
-	if err := s.CheckAvailability(); err != nil {
-		status.IsActive = false
-		status.Error = err
-	}
+	return s.GetProviderStatusContext(s.options.Context)
+}
 
-	return status
+// GetProviderStatusContext returns the status of the provider, ctx bounds the availability check
+func (s *SQLiteProvider) GetProviderStatusContext(ctx context.Context) Status {
+	return s.conn.status(ctx, s.CheckAvailabilityContext(ctx))
 }
 
-// MigrateDatabase migrates the database to the latest version
+// MigrateDatabase returns the migration engine, it runs with the options context
 func (s *SQLiteProvider) MigrateDatabase() migration.Migration {
-	// TODO implement me
-	panic("implement me")
+	return s.MigrateDatabaseContext(s.options.Context)
 }
 
-// Disconnect disconnects from the data provider
+// MigrateDatabaseContext returns the migration engine, it runs with ctx
+func (s *SQLiteProvider) MigrateDatabaseContext(ctx context.Context) migration.Migration {
+	return s.migration.WithContext(ctx)
+}
+
+// Disconnect waits up to the shutdown timeout for the connections in use, then closes the pool
 func (s *SQLiteProvider) Disconnect() error {
-	return s.dbHandle.Close()
+	ctx, cancel := context.WithTimeout(s.options.Context, s.options.shutdownTimeout())
+	defer cancel()
+
+	return s.DisconnectContext(ctx)
+}
+
+// DisconnectContext stops accepting work through the provider and closes the pool once the connections
+// in use are released or ctx is done
+func (s *SQLiteProvider) DisconnectContext(ctx context.Context) error {
+	return s.conn.shutdown(ctx)
 }
 
 // GetConnection returns the connection to the data provider
 func (s *SQLiteProvider) GetConnection() *sqlx.DB {
-	return s.dbHandle
+	return s.conn.db()
 }
 
 // CheckAvailability checks if the data provider is available
 func (s *SQLiteProvider) CheckAvailability() error {
-	ctx, cancel := context.WithTimeout(s.Context, 5)
+	return s.CheckAvailabilityContext(s.options.Context)
+}
+
+// CheckAvailabilityContext checks if the data provider is available within ctx and the health timeout
+func (s *SQLiteProvider) CheckAvailabilityContext(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, s.options.healthTimeout())
 	defer cancel()
 
-	return s.dbHandle.PingContext(ctx)
+	return s.conn.check(ctx, s.options.HealthQuery)
 }
 
 // ReconnectDatabase reconnects to the database
 func (s *SQLiteProvider) ReconnectDatabase() error {
-	return s.CheckAvailability()
+	return s.ReconnectDatabaseContext(s.options.Context)
+}
+
+// ReconnectDatabaseContext reconnects to the database, ctx cancels the retries
+func (s *SQLiteProvider) ReconnectDatabaseContext(ctx context.Context) error {
+	return s.conn.reconnect(ctx, s.options.Reconnect)
 }
 
 // InitializeDatabase initializes the database
 func (s *SQLiteProvider) InitializeDatabase(schema string) error {
-	_, err := s.dbHandle.Exec(schema)
-	return err
+	return s.InitializeDatabaseContext(s.options.Context, schema)
+}
+
+// InitializeDatabaseContext initializes the database with ctx
+func (s *SQLiteProvider) InitializeDatabaseContext(ctx context.Context, schema string) error {
+	if err := s.conn.err(); err != nil {
+		return err
+	}
+
+	if _, err := s.GetConnection().ExecContext(ctx, schema); err != nil {
+		return err
+	}
+
+	s.initSchema = schema
+	return nil
 }
 
 // RevertDatabase reverts the database to the specified version
 func (s *SQLiteProvider) RevertDatabase(targetVersion int) error {
-	// TODO implement me
-	panic("implement me")
+	return s.RevertDatabaseContext(s.options.Context, targetVersion)
+}
+
+// RevertDatabaseContext reverts the database to the specified version with ctx
+func (s *SQLiteProvider) RevertDatabaseContext(ctx context.Context, targetVersion int) error {
+	if err := s.conn.err(); err != nil {
+		return err
+	}
+
+	_, err := s.MigrateDatabaseContext(ctx).RevertTo(targetVersion)
+	return err
 }
 
 // ResetDatabase resets the database
 func (s *SQLiteProvider) ResetDatabase() error {
-	// TODO implement me
-	panic("implement me")
+	return s.ResetDatabaseContext(s.options.Context)
 }
 
-// NewSQLiteProvider creates a new SQLite provider instance
-func NewSQLiteProvider(options *Options) (*SQLiteProvider, error) {
-	driverName = options.Driver
-	dbHandle, err := sqlx.Connect("sqlite", options.ConnectionString)
-	if err != nil {
-		return nil, err
+// ResetDatabaseContext resets the database with ctx
+func (s *SQLiteProvider) ResetDatabaseContext(ctx context.Context) error {
+	if err := s.conn.err(); err != nil {
+		return err
 	}
 
-	dbHandle.SetMaxOpenConns(1)
+	if err := resetSQLite(ctx, s.GetConnection(), s.options); err != nil {
+		return err
+	}
 
-	if err = dbHandle.PingContext(options.Context); err != nil {
+	return reapplySchema(ctx, s, s.options, s.initSchema)
+}
+
+// NewSQLiteProvider creates a new SQLite provider instance
+func NewSQLiteProvider(options *Options) (*SQLiteProvider, error) {
+	conn, err := newConnection(options.Context, "sqlite", options)
+	if err != nil {
 		return nil, err
 	}
 
 	return &SQLiteProvider{
-		dbHandle: dbHandle,
-		Context:  options.Context,
+		conn:      conn,
+		options:   options,
+		migration: newMigration(options.Context, conn, options),
 	}, nil
 }
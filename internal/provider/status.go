@@ -0,0 +1,101 @@
+package provider
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// versionQueries read the server version of each driver
+var versionQueries = map[string]string{
+	PostgresSQLDatabaseProviderName: "SHOW server_version",
+	MySQLDatabaseProviderName:       "SELECT VERSION()",
+	OracleDatabaseProviderName:      "SELECT banner FROM v$version WHERE ROWNUM = 1",
+	SQLiteDataProviderName:          "SELECT sqlite_version()",
+	MemoryDataProviderName:          "SELECT sqlite_version()",
+}
+
+// Status is the state of a provider, it is meant to be serialized by health endpoints
+type Status struct {
+	Driver        string        `json:"driver"`
+	Error         error         `json:"error,omitempty"`
+	IsActive      bool          `json:"is_active"`
+	ServerVersion string        `json:"server_version,omitempty"`
+	Uptime        time.Duration `json:"uptime"`
+	LastPing      time.Time     `json:"last_ping,omitempty"`
+	PingLatency   time.Duration `json:"ping_latency"`
+	Stats         sql.DBStats   `json:"stats"`
+}
+
+// statusJSON is the wire format of Status, the error is a string and durations use time.Duration.String
+type statusJSON struct {
+	Driver        string      `json:"driver"`
+	Error         string      `json:"error,omitempty"`
+	IsActive      bool        `json:"is_active"`
+	ServerVersion string      `json:"server_version,omitempty"`
+	Uptime        string      `json:"uptime"`
+	LastPing      *time.Time  `json:"last_ping,omitempty"`
+	PingLatency   string      `json:"ping_latency"`
+	Stats         sql.DBStats `json:"stats"`
+}
+
+// MarshalJSON renders the error as its message and the durations as strings such as 1m30s
+func (s Status) MarshalJSON() ([]byte, error) {
+	out := statusJSON{
+		Driver:        s.Driver,
+		IsActive:      s.IsActive,
+		ServerVersion: s.ServerVersion,
+		Uptime:        s.Uptime.String(),
+		PingLatency:   s.PingLatency.String(),
+		Stats:         s.Stats,
+	}
+
+	if s.Error != nil {
+		out.Error = s.Error.Error()
+	}
+
+	if !s.LastPing.IsZero() {
+		out.LastPing = &s.LastPing
+	}
+
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON reads the format written by MarshalJSON, the error only keeps its message
+func (s *Status) UnmarshalJSON(data []byte) error {
+	var in statusJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+
+	*s = Status{
+		Driver:        in.Driver,
+		IsActive:      in.IsActive,
+		ServerVersion: in.ServerVersion,
+		Stats:         in.Stats,
+	}
+
+	if in.Error != "" {
+		s.Error = errors.New(in.Error)
+	}
+
+	if in.LastPing != nil {
+		s.LastPing = *in.LastPing
+	}
+
+	var err error
+	if in.Uptime != "" {
+		if s.Uptime, err = time.ParseDuration(in.Uptime); err != nil {
+			return err
+		}
+	}
+
+	if in.PingLatency != "" {
+		if s.PingLatency, err = time.ParseDuration(in.PingLatency); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
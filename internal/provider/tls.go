@@ -0,0 +1,162 @@
+package provider
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"os"
+)
+
+// TLSMode selects how the server certificate is checked, the names follow the lib/pq sslmode values
+type TLSMode string
+
+const (
+	// TLSDisable connects without TLS
+	TLSDisable TLSMode = "disable"
+
+	// TLSRequire encrypts the connection without checking the server certificate
+	TLSRequire TLSMode = "require"
+
+	// TLSVerifyCA checks that the server certificate is signed by a trusted CA
+	TLSVerifyCA TLSMode = "verify-ca"
+
+	// TLSVerifyFull checks the CA and that the certificate matches the server name
+	TLSVerifyFull TLSMode = "verify-full"
+)
+
+// ErrInvalidTLS is returned when the TLS options are inconsistent or not supported by the driver
+var ErrInvalidTLS = errors.New("invalid tls options")
+
+// TLSOptions configures TLS for the network providers, an empty Mode keeps the driver default
+type TLSOptions struct {
+	Mode TLSMode
+
+	// CAFile is a PEM bundle of the CAs trusted to sign the server certificate, the system pool is used
+	// when it is empty
+	CAFile string
+
+	// CertFile and KeyFile are the PEM client certificate and key for mutual TLS
+	CertFile string
+	KeyFile  string
+
+	// ServerName is the name checked in the server certificate when it differs from Host, for Oracle it
+	// is the distinguished name matched with ssl_server_cert_dn
+	ServerName string
+
+	// WalletDir is the Oracle wallet directory holding the certificates used by godror
+	WalletDir string
+}
+
+// enabled tells if TLS is requested
+func (t TLSOptions) enabled() bool {
+	return t.Mode != "" && t.Mode != TLSDisable
+}
+
+// validate checks the TLS options for the driver
+func (t TLSOptions) validate(driver string) []error {
+	if t == (TLSOptions{}) {
+		return nil
+	}
+
+	var errs []error
+	switch t.Mode {
+	case "", TLSDisable, TLSRequire, TLSVerifyCA, TLSVerifyFull:
+	default:
+		errs = append(errs, fmt.Errorf("%w: unknown mode %q", ErrInvalidTLS, t.Mode))
+	}
+
+	if (t.CertFile == "") != (t.KeyFile == "") {
+		errs = append(errs, fmt.Errorf("%w: client certificate and key must be set together", ErrInvalidTLS))
+	}
+
+	if t.Mode == TLSDisable && (t.CAFile != "" || t.CertFile != "" || t.ServerName != "" || t.WalletDir != "") {
+		errs = append(errs, fmt.Errorf("%w: certificates are set but tls is disabled", ErrInvalidTLS))
+	}
+
+	switch driver {
+	case PostgresSQLDatabaseProviderName:
+		if t.ServerName != "" {
+			errs = append(errs, fmt.Errorf("%w: lib/pq verifies the certificate against the host, server name is not supported", ErrInvalidTLS))
+		}
+		if t.WalletDir != "" {
+			errs = append(errs, fmt.Errorf("%w: wallets are only used by oracle", ErrInvalidTLS))
+		}
+	case MySQLDatabaseProviderName:
+		if t.WalletDir != "" {
+			errs = append(errs, fmt.Errorf("%w: wallets are only used by oracle", ErrInvalidTLS))
+		}
+	case OracleDatabaseProviderName:
+		if t.CAFile != "" || t.CertFile != "" {
+			errs = append(errs, fmt.Errorf("%w: oracle reads certificates from the wallet directory", ErrInvalidTLS))
+		}
+	case SQLiteDataProviderName, MemoryDataProviderName:
+		errs = append(errs, fmt.Errorf("%w: driver %s does not use the network", ErrInvalidTLS, driver))
+	}
+
+	return errs
+}
+
+// Config builds the crypto/tls configuration used by go-sql-driver/mysql, verify-ca checks the chain
+// without the host name
+func (t TLSOptions) Config() (*tls.Config, error) {
+	cfg := &tls.Config{
+		ServerName: t.ServerName,
+		MinVersion: tls.VersionTLS12,
+	}
+
+	if t.CAFile != "" {
+		pem, err := os.ReadFile(t.CAFile)
+		if err != nil {
+			return nil, err
+		}
+
+		cfg.RootCAs = x509.NewCertPool()
+		if !cfg.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("%w: no certificate found in %s", ErrInvalidTLS, t.CAFile)
+		}
+	}
+
+	if t.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	switch t.Mode {
+	case TLSRequire:
+		cfg.InsecureSkipVerify = true
+	case TLSVerifyCA:
+		// the standard verification also checks the host name, verify the chain only
+		cfg.InsecureSkipVerify = true
+		cfg.VerifyConnection = func(state tls.ConnectionState) error {
+			if len(state.PeerCertificates) == 0 {
+				return fmt.Errorf("%w: server sent no certificate", ErrInvalidTLS)
+			}
+
+			intermediates := x509.NewCertPool()
+			for _, cert := range state.PeerCertificates[1:] {
+				intermediates.AddCert(cert)
+			}
+
+			_, err := state.PeerCertificates[0].Verify(x509.VerifyOptions{
+				Roots:         cfg.RootCAs,
+				Intermediates: intermediates,
+			})
+			return err
+		}
+	}
+
+	return cfg, nil
+}
+
+// mysqlTLSName is the name the tls.Config is registered under with go-sql-driver/mysql, it only depends
+// on the options so providers sharing the settings share the registration
+func (t TLSOptions) mysqlTLSName() string {
+	h := fnv.New64a()
+	_, _ = fmt.Fprintf(h, "%s\x00%s\x00%s\x00%s\x00%s", t.Mode, t.CAFile, t.CertFile, t.KeyFile, t.ServerName)
+	return fmt.Sprintf("dataprovider-%x", h.Sum64())
+}
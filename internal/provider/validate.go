@@ -0,0 +1,109 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// MaxPoolSize is the largest pool size accepted by Validate
+const MaxPoolSize = 1000
+
+var (
+	// ErrUnsupportedDriver is returned when the driver is neither built in nor registered
+	ErrUnsupportedDriver = errors.New("unsupported driver")
+
+	// ErrMissingHost is returned when a network driver has no host
+	ErrMissingHost = errors.New("host is required")
+
+	// ErrMissingPort is returned when a network driver has no port
+	ErrMissingPort = errors.New("port is required")
+
+	// ErrInvalidPort is returned when the port is outside 1-65535
+	ErrInvalidPort = errors.New("port is out of range")
+
+	// ErrMissingUsername is returned when a network driver has no username
+	ErrMissingUsername = errors.New("username is required")
+
+	// ErrMissingName is returned when the database name, service name or SQLite file is missing
+	ErrMissingName = errors.New("database name is required")
+
+	// ErrInvalidPoolSize is returned when the pool size is negative or above MaxPoolSize
+	ErrInvalidPoolSize = errors.New("pool size is out of range")
+
+	// ErrInvalidReconnect is returned when the reconnect backoff options are out of range
+	ErrInvalidReconnect = errors.New("invalid reconnect options")
+
+	// ErrInvalidIdentifier is returned when the schema or the tables prefix is not a plain SQL identifier
+	ErrInvalidIdentifier = errors.New("invalid identifier")
+)
+
+// identifierPattern matches the unquoted identifiers accepted by every supported database
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Validate checks the options before connecting, every problem is reported and each one matches its
+// Err* value with errors.Is. Host, port and username are not required when ConnectionString is set
+func (o *Options) Validate() error {
+	var errs []error
+
+	switch o.Driver {
+	case PostgresSQLDatabaseProviderName, MySQLDatabaseProviderName, OracleDatabaseProviderName:
+		if o.ConnectionString == "" {
+			errs = append(errs, o.validateNetwork()...)
+		}
+	case SQLiteDataProviderName:
+		if o.ConnectionString == "" && o.Name == "" {
+			errs = append(errs, fmt.Errorf("%w: sqlite needs a file name or a connection string", ErrMissingName))
+		}
+	case MemoryDataProviderName:
+	default:
+		if _, ok := lookupDialect(o.Driver); !ok {
+			errs = append(errs, fmt.Errorf("%w %q", ErrUnsupportedDriver, o.Driver))
+		}
+	}
+
+	errs = append(errs, o.TLS.validate(o.Driver)...)
+	errs = append(errs, o.Pool.validate(o.Driver)...)
+	errs = append(errs, o.Reconnect.validate()...)
+
+	if o.PoolSize < 0 || o.PoolSize > MaxPoolSize {
+		errs = append(errs, fmt.Errorf("%w: %d is not within 0-%d", ErrInvalidPoolSize, o.PoolSize, MaxPoolSize))
+	}
+
+	if o.Schema != "" && !identifierPattern.MatchString(o.Schema) {
+		errs = append(errs, fmt.Errorf("%w: schema %q", ErrInvalidIdentifier, o.Schema))
+	}
+
+	if o.SQLTablesPrefix != "" && !identifierPattern.MatchString(o.SQLTablesPrefix) {
+		errs = append(errs, fmt.Errorf("%w: tables prefix %q", ErrInvalidIdentifier, o.SQLTablesPrefix))
+	}
+
+	return errors.Join(errs...)
+}
+
+// validateNetwork checks the fields used to reach a database server
+func (o *Options) validateNetwork() []error {
+	var errs []error
+
+	if o.Host == "" {
+		errs = append(errs, fmt.Errorf("%w for driver %s", ErrMissingHost, o.Driver))
+	}
+
+	switch {
+	case o.Port == 0:
+		errs = append(errs, fmt.Errorf("%w for driver %s", ErrMissingPort, o.Driver))
+	case o.Port < 0 || o.Port > 65535:
+		errs = append(errs, fmt.Errorf("%w: %d", ErrInvalidPort, o.Port))
+	}
+
+	if o.Username == "" {
+		errs = append(errs, fmt.Errorf("%w for driver %s", ErrMissingUsername, o.Driver))
+	}
+
+	// postgres and mysql connect without selecting a database, oracle needs the service name
+	if o.Driver == OracleDatabaseProviderName && o.Name == "" {
+		errs = append(errs, fmt.Errorf("%w: oracle needs a service name", ErrMissingName))
+	}
+
+	return errs
+}
@@ -0,0 +1,5 @@
+CREATE TABLE users (
+    id SERIAL PRIMARY KEY,
+    ip_address TEXT,
+    city TEXT
+);
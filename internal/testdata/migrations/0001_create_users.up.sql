@@ -0,0 +1,5 @@
+CREATE TABLE users (
+    id INTEGER PRIMARY KEY,
+    ip_address TEXT,
+    city TEXT
+);
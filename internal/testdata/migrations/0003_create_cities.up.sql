@@ -0,0 +1,5 @@
+CREATE TABLE cities (
+    id INTEGER PRIMARY KEY,
+    name TEXT NOT NULL
+);
+CREATE INDEX idx_cities_name ON cities (name);
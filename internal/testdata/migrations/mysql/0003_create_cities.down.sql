@@ -0,0 +1,2 @@
+DROP INDEX idx_cities_name ON cities;
+DROP TABLE cities;
@@ -0,0 +1,88 @@
+package dataprovider
+
+import (
+	"github.com/inovacc/dataprovider/internal/migration"
+	"github.com/jmoiron/sqlx"
+)
+
+// Migration applies, reverts and inspects the versioned migrations of a database
+type Migration = migration.Migration
+
+// MigrationOptions configures a migration engine created with NewMigration
+type MigrationOptions = migration.Options
+
+// MigrationSource describes where migrations are loaded from: a directory on a filesystem and Go migrations
+type MigrationSource = migration.Source
+
+// GoMigration is a migration written in Go, it shares the version sequence with the SQL files
+type GoMigration = migration.GoMigration
+
+// GoMigrationFunc applies or reverts a Go migration inside the migration transaction
+type GoMigrationFunc = migration.GoMigrationFunc
+
+// AppliedMigration is a migration recorded in the bookkeeping table
+type AppliedMigration = migration.AppliedMigration
+
+// PlannedMigration is a pending migration with the statements Migrate would execute
+type PlannedMigration = migration.PlannedMigration
+
+// MigrationStatus is the state of a single migration version
+type MigrationStatus = migration.MigrationStatus
+
+// MigrationState is the state of a migration in a status report
+type MigrationState = migration.State
+
+// DriftError reports an applied migration whose source changed after it was applied
+type DriftError = migration.DriftError
+
+// LockError reports who holds the migration lock when waiting for it failed
+type LockError = migration.LockError
+
+const (
+	// MigrationApplied is a migration recorded in the bookkeeping table
+	MigrationApplied = migration.StateApplied
+
+	// MigrationPending is a migration that Migrate would apply
+	MigrationPending = migration.StatePending
+
+	// MigrationMissing is a recorded migration whose file or Go migration is gone
+	MigrationMissing = migration.StateMissing
+)
+
+// DefaultMigrationsTable is the name of the bookkeeping table before the tables prefix is applied
+const DefaultMigrationsTable = migration.DefaultTableName
+
+// DefaultStaleLockAfter is the age after which the SQLite migration lock is reported as stale
+const DefaultStaleLockAfter = migration.DefaultStaleLockAfter
+
+var (
+	// ErrNoMigrationsPath is returned when the engine has neither a migrations path nor Go migrations
+	ErrNoMigrationsPath = migration.ErrNoMigrationsPath
+
+	// ErrMissingDownScript is returned when a migration that must be reverted has no down script
+	ErrMissingDownScript = migration.ErrMissingDownScript
+
+	// ErrVersionNotApplied is returned when the revert target is not a recorded version
+	ErrVersionNotApplied = migration.ErrVersionNotApplied
+
+	// ErrVersionGap is returned by Validate when the migration versions are not contiguous
+	ErrVersionGap = migration.ErrVersionGap
+
+	// ErrChecksumMismatch is returned by Validate when an applied migration changed after it was applied
+	ErrChecksumMismatch = migration.ErrChecksumMismatch
+
+	// ErrUnknownMigration is returned by Validate when an applied migration has no file or Go migration anymore
+	ErrUnknownMigration = migration.ErrUnknownMigration
+
+	// ErrMigrationsLocked is returned when the migration lock could not be taken before the context was done
+	ErrMigrationsLocked = migration.ErrLocked
+
+	// ErrStaleMigrationLock is returned when the SQLite migration lock is older than its stale age
+	ErrStaleMigrationLock = migration.ErrStaleLock
+)
+
+// NewMigration creates a migration engine on an existing connection, it is what
+// Provider.MigrateDatabase uses with the provider options
+func NewMigration(db *sqlx.DB, options MigrationOptions) Migration {
+	return migration.NewMigration(db, options)
+}
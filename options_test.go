@@ -0,0 +1,93 @@
+package dataprovider
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOptionsValidate(t *testing.T) {
+	network := func(driver string, optsFn ...OptionFunc) *Options {
+		base := []OptionFunc{WithDriver(driver), WithHost("db.local"), WithPort(5432), WithUsername("app"), WithName("orders")}
+		return NewOptions(append(base, optsFn...)...)
+	}
+
+	tests := []struct {
+		name    string
+		options *Options
+		want    []error
+	}{
+		{name: "memory", options: NewOptions(WithMemoryDB())},
+		{name: "sqlite", options: NewOptions(WithSqliteDB("app", t.TempDir()))},
+		{name: "postgres", options: network(PostgresSQLDatabaseProviderName)},
+		{name: "connection string", options: NewOptions(WithDriver(MySQLDatabaseProviderName), WithConnectionString("root@tcp(db)/shop"))},
+		{name: "unsupported driver", options: NewOptions(WithDriver("sqlserver")), want: []error{ErrUnsupportedDriver}},
+		{
+			name:    "empty network options",
+			options: NewOptions(WithDriver(OracleDatabaseProviderName)),
+			want:    []error{ErrMissingHost, ErrMissingPort, ErrMissingUsername, ErrMissingName},
+		},
+		{name: "port range", options: network(MySQLDatabaseProviderName, WithPort(70000)), want: []error{ErrInvalidPort}},
+		{name: "sqlite without file", options: NewOptions(WithDriver(SQLiteDataProviderName)), want: []error{ErrMissingName}},
+		{name: "pool size", options: NewOptions(WithMemoryDB(), WithPoolSize(-1)), want: []error{ErrInvalidPoolSize}},
+		{
+			name:    "identifiers",
+			options: NewOptions(WithMemoryDB(), WithSchema("public; DROP"), WithSQLTablesPrefix("app-")),
+			want:    []error{ErrInvalidIdentifier},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.options.Validate()
+			if len(tt.want) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+
+			for _, want := range tt.want {
+				assert.ErrorIs(t, err, want)
+			}
+		})
+	}
+}
+
+func TestNewDataProviderValidates(t *testing.T) {
+	_, err := NewDataProvider(NewOptions(WithDriver(PostgresSQLDatabaseProviderName), WithPort(5432)))
+	assert.ErrorIs(t, err, ErrMissingHost)
+	assert.ErrorIs(t, err, ErrMissingUsername)
+
+	_, err = NewDataProvider(NewOptions(WithDriver("sqlserver")))
+	assert.ErrorIs(t, err, ErrUnsupportedDriver)
+}
+
+func TestPoolOptions(t *testing.T) {
+	maxOpen := func(optsFn ...OptionFunc) int {
+		t.Helper()
+
+		provider := Must(NewDataProvider(NewOptions(optsFn...)))
+		defer func() { _ = provider.Disconnect() }()
+
+		return provider.GetConnection().Stats().MaxOpenConnections
+	}
+
+	dir := t.TempDir()
+
+	assert.Equal(t, 1, maxOpen(WithSqliteDB("pool_default", dir)))
+	assert.Equal(t, 1, maxOpen(WithMemoryDB()))
+	assert.Equal(t, DefaultSQLiteWALReaders, maxOpen(WithSqliteDB("pool_wal", dir), WithParam("_pragma", "journal_mode(WAL)")))
+
+	// the journal mode is persisted in the file and detected without the parameter
+	assert.Equal(t, DefaultSQLiteWALReaders, maxOpen(WithSqliteDB("pool_wal", dir)))
+
+	assert.Equal(t, 2, maxOpen(WithSqliteDB("pool_size", dir), WithPoolSize(2)))
+	assert.Equal(t, 3, maxOpen(WithSqliteDB("pool_size", dir), WithPoolSize(2), WithMaxOpenConns(3)))
+	assert.Equal(t, 0, maxOpen(WithSqliteDB("pool_unlimited", dir), WithMaxOpenConns(-1)))
+	assert.Equal(t, 3, maxOpen(WithMemoryDB(), WithPool(PoolOptions{MaxOpenConns: 3, MaxIdleConns: 3})))
+
+	assert.ErrorIs(t, NewOptions(WithMemoryDB(), WithMaxOpenConns(2), WithMaxIdleConns(4)).Validate(), ErrInvalidPoolSize)
+	assert.ErrorIs(t, NewOptions(WithMemoryDB(), WithConnMaxLifetime(time.Minute)).Validate(), ErrInvalidPoolSize)
+	assert.ErrorIs(t, NewOptions(WithMemoryDB(), WithMaxOpenConns(MaxPoolSize+1)).Validate(), ErrInvalidPoolSize)
+	assert.NoError(t, NewOptions(WithSqliteDB("pool", dir), WithConnMaxLifetime(time.Minute), WithConnMaxIdleTime(-1)).Validate())
+}
@@ -0,0 +1,205 @@
+package dataprovider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"sort"
+	"sync"
+)
+
+var (
+	// ErrProviderNotFound is returned by Registry.Get when no provider has the name
+	ErrProviderNotFound = errors.New("provider not found")
+
+	// ErrProviderExists is returned when a name of the registry is already taken
+	ErrProviderExists = errors.New("provider already exists")
+)
+
+// Registry holds named providers, for services that talk to several databases
+type Registry struct {
+	mu        sync.RWMutex
+	providers map[string]Provider
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{providers: make(map[string]Provider)}
+}
+
+// OpenRegistry opens a provider with NewDataProvider for every entry of configs, the options can come
+// from LoadOptions with a prefix per database. When one fails the providers already opened are
+// disconnected
+func OpenRegistry(configs map[string]*Options) (*Registry, error) {
+	r := NewRegistry()
+
+	for _, name := range sortedNames(configs) {
+		if _, err := r.Open(name, configs[name]); err != nil {
+			return nil, errors.Join(err, r.Disconnect())
+		}
+	}
+
+	return r, nil
+}
+
+// Open creates a provider with NewDataProvider and registers it under name
+func (r *Registry) Open(name string, options *Options) (Provider, error) {
+	if err := r.reserve(name); err != nil {
+		return nil, err
+	}
+
+	p, err := NewDataProvider(options)
+	if err != nil {
+		return nil, fmt.Errorf("provider %q: %w", name, err)
+	}
+
+	if err = r.Add(name, p); err != nil {
+		return nil, errors.Join(err, p.Disconnect())
+	}
+
+	return p, nil
+}
+
+// Add registers an open provider under name
+func (r *Registry) Add(name string, p Provider) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.available(name); err != nil {
+		return err
+	}
+
+	r.providers[name] = p
+	return nil
+}
+
+// reserve checks that name can be registered before a provider is opened for it
+func (r *Registry) reserve(name string) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.available(name)
+}
+
+// available checks the name, the caller holds the lock
+func (r *Registry) available(name string) error {
+	if name == "" {
+		return fmt.Errorf("%w: the provider name is empty", ErrMissingName)
+	}
+
+	if _, ok := r.providers[name]; ok {
+		return fmt.Errorf("%w: %q", ErrProviderExists, name)
+	}
+
+	return nil
+}
+
+// Get returns the provider registered under name
+func (r *Registry) Get(name string) (Provider, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	p, ok := r.providers[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrProviderNotFound, name)
+	}
+
+	return p, nil
+}
+
+// Names returns the registered names in order
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return sortedNames(r.providers)
+}
+
+// GetProviderStatus returns the status of every provider by name, the providers are checked concurrently
+// with the context of their own options
+func (r *Registry) GetProviderStatus() map[string]Status {
+	return r.statuses(Provider.GetProviderStatus)
+}
+
+// GetProviderStatusContext returns the status of every provider by name with ctx
+func (r *Registry) GetProviderStatusContext(ctx context.Context) map[string]Status {
+	return r.statuses(func(p Provider) Status { return p.GetProviderStatusContext(ctx) })
+}
+
+// statuses collects the status of every provider concurrently
+func (r *Registry) statuses(status func(Provider) Status) map[string]Status {
+	statuses := make(map[string]Status)
+
+	var mu sync.Mutex
+	_ = r.each(func(name string, p Provider) error {
+		s := status(p)
+
+		mu.Lock()
+		statuses[name] = s
+		mu.Unlock()
+		return nil
+	})
+
+	return statuses
+}
+
+// Disconnect disconnects every provider concurrently and empties the registry, each provider waits up
+// to its own shutdown timeout
+func (r *Registry) Disconnect() error {
+	return r.close(Provider.Disconnect)
+}
+
+// DisconnectContext disconnects every provider concurrently with ctx and empties the registry
+func (r *Registry) DisconnectContext(ctx context.Context) error {
+	return r.close(func(p Provider) error { return p.DisconnectContext(ctx) })
+}
+
+// close removes the providers from the registry and disconnects them
+func (r *Registry) close(disconnect func(Provider) error) error {
+	r.mu.Lock()
+	providers := r.providers
+	r.providers = make(map[string]Provider)
+	r.mu.Unlock()
+
+	return forEach(providers, func(_ string, p Provider) error { return disconnect(p) })
+}
+
+// each calls fn concurrently for every registered provider
+func (r *Registry) each(fn func(name string, p Provider) error) error {
+	r.mu.RLock()
+	providers := maps.Clone(r.providers)
+	r.mu.RUnlock()
+
+	return forEach(providers, fn)
+}
+
+// forEach calls fn concurrently for every provider and joins the errors, prefixed with the provider names
+func forEach(providers map[string]Provider, fn func(name string, p Provider) error) error {
+	errs := make([]error, len(providers))
+	names := sortedNames(providers)
+
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := fn(name, providers[name]); err != nil {
+				errs[i] = fmt.Errorf("provider %q: %w", name, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// sortedNames returns the keys of m in order
+func sortedNames[T any](m map[string]T) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,80 @@
+package dataprovider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry(t *testing.T) {
+	dir := t.TempDir()
+	registry, err := OpenRegistry(map[string]*Options{
+		"cache":   NewOptions(WithMemoryDB()),
+		"orders":  NewOptions(WithSqliteDB("orders", dir)),
+		"reports": NewOptions(WithSqliteDB("reports", dir)),
+	})
+	require.NoError(t, err)
+	defer func() { _ = registry.Disconnect() }()
+
+	assert.Equal(t, []string{"cache", "orders", "reports"}, registry.Names())
+
+	orders, err := registry.Get("orders")
+	require.NoError(t, err)
+	require.NoError(t, orders.InitializeDatabase("CREATE TABLE orders (id INTEGER PRIMARY KEY)"))
+
+	_, err = registry.Get("billing")
+	assert.ErrorIs(t, err, ErrProviderNotFound)
+
+	_, err = registry.Open("orders", NewOptions(WithMemoryDB()))
+	assert.ErrorIs(t, err, ErrProviderExists)
+
+	statuses := registry.GetProviderStatus()
+	require.Len(t, statuses, 3)
+	assert.Equal(t, MemoryDataProviderName, statuses["cache"].Driver)
+	assert.Equal(t, SQLiteDataProviderName, statuses["orders"].Driver)
+	for name, status := range statuses {
+		assert.True(t, status.IsActive, name)
+	}
+
+	cache, err := registry.Get("cache")
+	require.NoError(t, err)
+	require.NoError(t, cache.Disconnect())
+	assert.False(t, registry.GetProviderStatus()["cache"].IsActive)
+
+	assert.NoError(t, registry.Disconnect())
+	assert.Empty(t, registry.Names())
+	assert.ErrorIs(t, orders.CheckAvailability(), ErrProviderClosed)
+}
+
+func TestRegistryStatusContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	registry := NewRegistry()
+	_, err := registry.Open("cache", NewOptions(WithMemoryDB(), WithContext(ctx)))
+	require.NoError(t, err)
+	defer func() { _ = registry.DisconnectContext(context.Background()) }()
+
+	assert.True(t, registry.GetProviderStatus()["cache"].IsActive)
+
+	// each provider is checked with the context of its options
+	cancel()
+	assert.False(t, registry.GetProviderStatus()["cache"].IsActive)
+	assert.True(t, registry.GetProviderStatusContext(context.Background())["cache"].IsActive)
+}
+
+func TestOpenRegistryFailure(t *testing.T) {
+	dir := t.TempDir()
+
+	// an entry that cannot be opened fails the whole registry
+	_, err := OpenRegistry(map[string]*Options{
+		"a": NewOptions(WithSqliteDB("a", dir)),
+		"b": NewOptions(WithDriver("cassandra")),
+	})
+	assert.ErrorIs(t, err, ErrUnsupportedDriver)
+	assert.ErrorContains(t, err, `provider "b"`)
+
+	_, err = NewRegistry().Open("", NewOptions(WithMemoryDB()))
+	assert.ErrorIs(t, err, ErrMissingName)
+}
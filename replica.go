@@ -0,0 +1,74 @@
+package dataprovider
+
+import (
+	"context"
+
+	"github.com/inovacc/dataprovider/internal/provider"
+)
+
+// ReplicaPolicy picks the replica serving a read among the healthy ones
+type ReplicaPolicy = provider.ReplicaPolicy
+
+// ReplicaOptions configures the replicas of a ReplicatedProvider, zero values pick RoundRobin and
+// DefaultHealthInterval
+type ReplicaOptions = provider.ReplicaOptions
+
+// ReplicaSet routes writes and transactions to the primary and balances reads over the healthy replicas
+type ReplicaSet = provider.ReplicaSet
+
+// RoundRobin returns a policy sending the reads to each replica in turn
+func RoundRobin() ReplicaPolicy {
+	return provider.RoundRobin()
+}
+
+// LeastConnections returns a policy sending the reads to the replica with the fewest connections in use
+func LeastConnections() ReplicaPolicy {
+	return provider.LeastConnections()
+}
+
+// ForcePrimary returns a context whose reads are served by the primary, for paths that must read their
+// own writes
+func ForcePrimary(ctx context.Context) context.Context {
+	return provider.ForcePrimary(ctx)
+}
+
+// ReplicatedProvider is a provider with read replicas. The Provider methods act on the primary, the
+// ReplicaSet methods route the queries, and reconnecting or disconnecting covers every node
+type ReplicatedProvider struct {
+	Provider
+	*ReplicaSet
+}
+
+// NewReplicatedProvider wraps primary with its replicas, the replicas are health checked in the
+// background until the provider is disconnected
+func NewReplicatedProvider(primary Provider, replicas []Provider, options ReplicaOptions) *ReplicatedProvider {
+	nodes := make([]provider.Node, len(replicas))
+	for i, r := range replicas {
+		nodes[i] = r
+	}
+
+	return &ReplicatedProvider{
+		Provider:   primary,
+		ReplicaSet: provider.NewReplicaSet(primary, nodes, options),
+	}
+}
+
+// ReconnectDatabase reconnects the primary and the replicas
+func (r *ReplicatedProvider) ReconnectDatabase() error {
+	return r.ReplicaSet.ReconnectDatabase()
+}
+
+// ReconnectDatabaseContext reconnects the primary and the replicas with ctx
+func (r *ReplicatedProvider) ReconnectDatabaseContext(ctx context.Context) error {
+	return r.ReplicaSet.ReconnectDatabaseContext(ctx)
+}
+
+// Disconnect disconnects the replicas, then the primary
+func (r *ReplicatedProvider) Disconnect() error {
+	return r.ReplicaSet.Disconnect()
+}
+
+// DisconnectContext disconnects the replicas, then the primary, with ctx
+func (r *ReplicatedProvider) DisconnectContext(ctx context.Context) error {
+	return r.ReplicaSet.DisconnectContext(ctx)
+}
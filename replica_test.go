@@ -0,0 +1,93 @@
+package dataprovider
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var _ Provider = (*ReplicatedProvider)(nil)
+
+// newNode creates a SQLite provider whose items table holds one row naming it
+func newNode(t *testing.T, dir, name string) Provider {
+	t.Helper()
+
+	node := Must(NewDataProvider(NewOptions(WithSqliteDB(name, dir))))
+	require.NoError(t, node.InitializeDatabase("CREATE TABLE items (node TEXT)"))
+	_, err := node.GetConnection().Exec("INSERT INTO items (node) VALUES (?)", name)
+	require.NoError(t, err)
+
+	return node
+}
+
+func TestReplicatedProvider(t *testing.T) {
+	dir := t.TempDir()
+	primary := newNode(t, dir, "primary")
+	replica1, replica2 := newNode(t, dir, "replica1"), newNode(t, dir, "replica2")
+
+	p := NewReplicatedProvider(primary, []Provider{replica1, replica2}, ReplicaOptions{HealthInterval: 10 * time.Millisecond})
+	defer func() { _ = p.Disconnect() }()
+
+	ctx := context.Background()
+	read := func(ctx context.Context) string {
+		var node string
+		require.NoError(t, p.GetContext(ctx, &node, "SELECT node FROM items LIMIT 1"))
+		return node
+	}
+
+	assert.Equal(t, 2, p.HealthyReplicas())
+	assert.Equal(t, []string{"replica1", "replica2", "replica1", "replica2"}, []string{read(ctx), read(ctx), read(ctx), read(ctx)})
+	assert.Equal(t, "primary", read(ForcePrimary(ctx)))
+
+	// writes and transactions go to the primary
+	_, err := p.ExecContext(ctx, "INSERT INTO items (node) VALUES ('written')")
+	require.NoError(t, err)
+
+	tx, err := p.BeginTxx(ctx, nil)
+	require.NoError(t, err)
+	var count int
+	require.NoError(t, tx.Get(&count, "SELECT COUNT(*) FROM items"))
+	assert.Equal(t, 2, count)
+	require.NoError(t, tx.Rollback())
+
+	require.NoError(t, p.SelectContext(ForcePrimary(ctx), &[]string{}, "SELECT node FROM items"))
+	assert.Same(t, primary.GetConnection(), p.GetConnection())
+
+	// an unhealthy replica stops serving reads, the primary serves them when none is left
+	require.NoError(t, replica1.Disconnect())
+	assert.Eventually(t, func() bool { return p.HealthyReplicas() == 1 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, []string{"replica2", "replica2"}, []string{read(ctx), read(ctx)})
+
+	require.NoError(t, replica2.Disconnect())
+	p.CheckReplicas()
+	assert.Equal(t, 0, p.HealthyReplicas())
+	assert.Equal(t, "primary", read(ctx))
+
+	assert.NoError(t, p.Disconnect())
+	assert.ErrorIs(t, primary.CheckAvailability(), ErrProviderClosed)
+}
+
+func TestReplicaLeastConnections(t *testing.T) {
+	dir := t.TempDir()
+	primary := newNode(t, dir, "primary")
+	replica1, replica2 := newNode(t, dir, "replica1"), newNode(t, dir, "replica2")
+
+	p := NewReplicatedProvider(primary, []Provider{replica1, replica2}, ReplicaOptions{Policy: LeastConnections()})
+	defer func() { _ = p.Disconnect() }()
+
+	ctx := context.Background()
+	assert.Same(t, replica1.GetConnection(), p.Reader(ctx))
+
+	// a connection held on replica1 sends the reads to replica2
+	conn, err := replica1.GetConnection().Connx(ctx)
+	require.NoError(t, err)
+
+	assert.Same(t, replica2.GetConnection(), p.Reader(ctx))
+	assert.Same(t, replica2.GetConnection(), p.Reader(ctx))
+
+	require.NoError(t, conn.Close())
+	assert.Same(t, replica1.GetConnection(), p.Reader(ctx))
+}
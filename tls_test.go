@@ -0,0 +1,197 @@
+package dataprovider
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/godror/godror/dsn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testCerts holds the PEM files of a local CA, a server certificate for db.local and a client certificate
+type testCerts struct {
+	ca, serverCert, serverKey, clientCert, clientKey string
+}
+
+func newTestCerts(t *testing.T) testCerts {
+	t.Helper()
+
+	dir := t.TempDir()
+	write := func(name, kind string, der []byte) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: kind, Bytes: der}), 0o600))
+		return path
+	}
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "dataprovider test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	require.NoError(t, err)
+
+	issue := func(serial int64, name string, usage x509.ExtKeyUsage) (string, string) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(serial),
+			Subject:      pkix.Name{CommonName: name},
+			DNSNames:     []string{name},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, caTemplate, &key.PublicKey, caKey)
+		require.NoError(t, err)
+
+		keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+		require.NoError(t, err)
+
+		return write(name+".crt", "CERTIFICATE", der), write(name+".key", "PRIVATE KEY", keyDER)
+	}
+
+	certs := testCerts{ca: write("ca.crt", "CERTIFICATE", caDER)}
+	certs.serverCert, certs.serverKey = issue(2, "db.local", x509.ExtKeyUsageServerAuth)
+	certs.clientCert, certs.clientKey = issue(3, "app", x509.ExtKeyUsageClientAuth)
+
+	return certs
+}
+
+func TestTLSOptionsDSN(t *testing.T) {
+	certs := newTestCerts(t)
+	tlsOptions := TLSOptions{Mode: TLSVerifyFull, CAFile: certs.ca, CertFile: certs.clientCert, KeyFile: certs.clientKey}
+
+	pg := NewOptions(WithDriver(PostgresSQLDatabaseProviderName), WithHost("db.local"), WithPort(5432), WithUsername("app"), WithTLS(tlsOptions))
+	require.NoError(t, pg.Validate())
+	assert.Contains(t, pg.DSN(), "sslmode=verify-full")
+	assert.Contains(t, pg.DSN(), "sslrootcert="+certs.ca)
+	assert.Contains(t, pg.DSN(), "sslcert="+certs.clientCert)
+	assert.Contains(t, pg.DSN(), "sslkey="+certs.clientKey)
+
+	// without TLS options postgres keeps the previous default
+	assert.Contains(t, NewOptions(WithDriver(PostgresSQLDatabaseProviderName), WithHost("db.local")).DSN(), "sslmode=disable")
+
+	my := NewOptions(WithDriver(MySQLDatabaseProviderName), WithHost("db.local"), WithPort(3306), WithUsername("app"), WithTLS(tlsOptions))
+	require.NoError(t, my.Validate())
+	assert.Regexp(t, `\?tls=dataprovider-[0-9a-f]+$`, my.DSN())
+
+	disabled := NewOptions(WithDriver(MySQLDatabaseProviderName), WithHost("db.local"), WithTLS(TLSOptions{Mode: TLSDisable}))
+	assert.True(t, strings.HasSuffix(disabled.DSN(), "?tls=false"))
+
+	ora := NewOptions(WithDriver(OracleDatabaseProviderName), WithHost("ora.local"), WithPort(2484), WithUsername("scott"),
+		WithName("ORCLPDB1"), WithTLS(TLSOptions{Mode: TLSVerifyFull, WalletDir: "/opt/wallet", ServerName: "CN=ora.local,O=Example"}))
+	require.NoError(t, ora.Validate())
+
+	params, err := dsn.Parse(ora.DSN())
+	require.NoError(t, err)
+	assert.Equal(t, `tcps://ora.local:2484/ORCLPDB1?ssl_server_dn_match=yes&ssl_server_cert_dn="CN=ora.local,O=Example"&wallet_location="/opt/wallet"`,
+		params.ConnectString)
+}
+
+func TestTLSOptionsValidate(t *testing.T) {
+	tests := []struct {
+		driver string
+		tls    TLSOptions
+	}{
+		{PostgresSQLDatabaseProviderName, TLSOptions{Mode: "prefer-maybe"}},
+		{PostgresSQLDatabaseProviderName, TLSOptions{Mode: TLSVerifyFull, CertFile: "client.crt"}},
+		{PostgresSQLDatabaseProviderName, TLSOptions{Mode: TLSVerifyFull, ServerName: "db.local"}},
+		{MySQLDatabaseProviderName, TLSOptions{Mode: TLSDisable, CAFile: "ca.crt"}},
+		{OracleDatabaseProviderName, TLSOptions{Mode: TLSRequire, CAFile: "ca.crt"}},
+		{SQLiteDataProviderName, TLSOptions{Mode: TLSRequire}},
+	}
+
+	for _, tt := range tests {
+		options := NewOptions(WithDriver(tt.driver), WithHost("db.local"), WithPort(1), WithUsername("app"), WithName("db"), WithTLS(tt.tls))
+		assert.ErrorIs(t, options.Validate(), ErrInvalidTLS, "%s %+v", tt.driver, tt.tls)
+	}
+}
+
+func TestTLSOptionsConfig(t *testing.T) {
+	certs := newTestCerts(t)
+
+	serverCert, err := tls.LoadX509KeyPair(certs.serverCert, certs.serverKey)
+	require.NoError(t, err)
+
+	caPEM, err := os.ReadFile(certs.ca)
+	require.NoError(t, err)
+	clientCAs := x509.NewCertPool()
+	require.True(t, clientCAs.AppendCertsFromPEM(caPEM))
+
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{serverCert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    clientCAs,
+	})
+	require.NoError(t, err)
+	defer func() { _ = listener.Close() }()
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			_ = conn.(*tls.Conn).Handshake()
+			_ = conn.Close()
+		}
+	}()
+
+	dial := func(options TLSOptions) error {
+		cfg, err := options.Config()
+		require.NoError(t, err)
+
+		conn, err := tls.DialWithDialer(&net.Dialer{Timeout: time.Second}, "tcp", listener.Addr().String(), cfg)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = conn.Close() }()
+		return conn.Handshake()
+	}
+
+	client := TLSOptions{CAFile: certs.ca, CertFile: certs.clientCert, KeyFile: certs.clientKey}
+
+	// the certificate is for db.local and the server is reached by IP
+	verifyCA := client
+	verifyCA.Mode = TLSVerifyCA
+	assert.NoError(t, dial(verifyCA))
+
+	verifyFull := client
+	verifyFull.Mode = TLSVerifyFull
+	assert.Error(t, dial(verifyFull))
+
+	verifyFull.ServerName = "db.local"
+	assert.NoError(t, dial(verifyFull))
+
+	// a CA that did not sign the server certificate is rejected by verify-ca
+	otherCA := newTestCerts(t).ca
+	untrusted := verifyCA
+	untrusted.CAFile = otherCA
+	assert.Error(t, dial(untrusted))
+
+	_, err = TLSOptions{CAFile: certs.clientKey}.Config()
+	assert.ErrorIs(t, err, ErrInvalidTLS)
+}